@@ -0,0 +1,7 @@
+package main
+
+import "pwner/utils"
+
+func Args() *utils.Arguments {
+	return utils.Args()
+}
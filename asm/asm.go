@@ -0,0 +1,74 @@
+package asm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"pwner/utils"
+	"strings"
+)
+
+type toolchain struct {
+	prefix    string
+	asFlags   []string
+	emulation string
+	syntax    string
+}
+
+var toolchains = map[string]toolchain{
+	"amd64":   {"", []string{"--64"}, "elf_x86_64", ".intel_syntax noprefix"},
+	"i386":    {"", []string{"--32"}, "elf_i386", ".intel_syntax noprefix"},
+	"aarch64": {"aarch64-linux-gnu-", nil, "aarch64linux", ""},
+	"arm":     {"arm-linux-gnueabihf-", nil, "armelf_linux_eabi", ""},
+	"mips":    {"mips-linux-gnu-", []string{"-EB"}, "elf32btsmip", ".set noreorder"},
+	"mipsel":  {"mipsel-linux-gnu-", []string{"-EL"}, "elf32ltsmip", ".set noreorder"},
+	"riscv64": {"riscv64-linux-gnu-", nil, "elf64lriscv", ""},
+}
+
+func Asm(code string, arch string, vma ...uint64) []byte {
+	arch = utils.Arch(arch)
+	tc, ok := toolchains[arch]
+	if !ok {
+		utils.Fatal("unsupported arch: %s", arch)
+	}
+
+	dir, err := os.MkdirTemp("", "pwner-asm-*")
+	if err != nil {
+		utils.Fatal("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "code.s")
+	object := filepath.Join(dir, "code.o")
+	linked := filepath.Join(dir, "code.elf")
+	output := filepath.Join(dir, "code.bin")
+
+	lines := []string{tc.syntax, ".section .text", ".global _start", "_start:"}
+	lines = append(lines, strings.Split(code, ";")...)
+	if err := os.WriteFile(source, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		utils.Fatal("failed to write assembly: %v", err)
+	}
+
+	run(tc.prefix+"as", append(tc.asFlags, "-o", object, source)...)
+
+	text := uint64(0)
+	if len(vma) > 0 {
+		text = vma[0]
+	}
+	run(tc.prefix+"ld", "-m", tc.emulation, "-N", "-e", "_start", fmt.Sprintf("-Ttext=0x%x", text), "-o", linked, object)
+	run(tc.prefix+"objcopy", "-O", "binary", "-j", ".text", linked, output)
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		utils.Fatal("failed to read assembled code: %v", err)
+	}
+	return data
+}
+
+func run(name string, args ...string) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		utils.Fatal("%s failed: %v\n%s", name, err, out)
+	}
+}
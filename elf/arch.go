@@ -0,0 +1,53 @@
+package elf
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"pwner/utils"
+)
+
+func (e *ELFer) Arch() string {
+	switch e.file.Machine {
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_386:
+		return "i386"
+	case elf.EM_AARCH64:
+		return "aarch64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_MIPS:
+		if e.file.ByteOrder == binary.LittleEndian {
+			return "mipsel"
+		}
+		return "mips"
+	case elf.EM_RISCV:
+		if e.file.Class == elf.ELFCLASS32 {
+			return "riscv32"
+		}
+		return "riscv64"
+	}
+	return e.file.Machine.String()
+}
+
+func (e *ELFer) Bits() int {
+	if e.file.Class == elf.ELFCLASS32 {
+		return 32
+	}
+	return 64
+}
+
+func (e *ELFer) Endian() utils.Endian {
+	if e.file.ByteOrder == binary.BigEndian {
+		return utils.BigEndian
+	}
+	return utils.LittleEndian
+}
+
+// SetContext makes this binary drive packing and assembly defaults. Loading
+// an ELF never does this implicitly, so helper binaries and libc leave the
+// context alone.
+func (e *ELFer) SetContext() {
+	utils.Ctx = utils.Context{Arch: e.Arch(), Bits: e.Bits(), Endian: e.Endian()}
+	utils.Debug("context: arch=%s bits=%d", utils.Ctx.Arch, utils.Ctx.Bits)
+}
@@ -0,0 +1,363 @@
+package elf
+
+import (
+	"debug/elf"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+const (
+	AuditImport   = "dangerous import"
+	AuditFormat   = "format string"
+	AuditOverflow = "stack overflow"
+	AuditFuncPtr  = "writable function pointer"
+	AuditRWX      = "rwx memory"
+)
+
+type Finding struct {
+	Kind   string
+	Addr   uint64
+	Detail string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] 0x%x: %s", f.Kind, f.Addr, f.Detail)
+}
+
+var dangerousImports = map[string]string{
+	"gets":     "reads a line without any bound",
+	"strcpy":   "copies without a bound",
+	"strcat":   "appends without a bound",
+	"sprintf":  "formats without a bound",
+	"vsprintf": "formats without a bound",
+	"system":   "runs a shell command",
+	"popen":    "runs a shell command",
+	"execve":   "executes a program",
+	"execl":    "executes a program",
+	"execlp":   "executes a program",
+	"execvp":   "executes a program",
+}
+
+// formatArgs maps printf-family functions to the index of their format
+// argument.
+var formatArgs = map[string]int{
+	"printf": 0, "vprintf": 0,
+	"fprintf": 1, "vfprintf": 1, "dprintf": 1, "vdprintf": 1,
+	"sprintf": 1, "vsprintf": 1, "syslog": 1,
+	"snprintf": 2, "vsnprintf": 2,
+	"__printf_chk": 1, "__vprintf_chk": 1,
+	"__fprintf_chk": 2, "__vfprintf_chk": 2, "__dprintf_chk": 2,
+	"__sprintf_chk": 3, "__vsprintf_chk": 3,
+	"__snprintf_chk": 4, "__vsnprintf_chk": 4,
+}
+
+// readArgs maps input functions to the indexes of their buffer and size
+// arguments.
+var readArgs = map[string][2]int{
+	"read":       {1, 2},
+	"__read_chk": {1, 2},
+	"recv":       {1, 2},
+	"recvfrom":   {1, 2},
+	"fgets":      {0, 1},
+}
+
+var sysvArgs = []x86asm.Reg{x86asm.RDI, x86asm.RSI, x86asm.RDX, x86asm.RCX, x86asm.R8, x86asm.R9}
+
+func (e *ELFer) Audit() []Finding {
+	var findings []Finding
+	findings = append(findings, e.auditImports()...)
+	if e.file.Machine == elf.EM_X86_64 {
+		findings = append(findings, e.auditCalls()...)
+	}
+	findings = append(findings, e.auditFuncPtrs()...)
+	findings = append(findings, e.auditSegments()...)
+	return findings
+}
+
+func (e *ELFer) auditImports() []Finding {
+	var findings []Finding
+	for _, name := range uniqueSorted(e.importedSymbols()) {
+		reason, ok := dangerousImports[name]
+		if !ok {
+			continue
+		}
+		addr := e.plt[name]
+		if addr == 0 {
+			addr = e.got[name].Addr
+		}
+		findings = append(findings, Finding{Kind: AuditImport, Addr: addr, Detail: fmt.Sprintf("%s %s", name, reason)})
+	}
+	return findings
+}
+
+type auditValue struct {
+	kind  int
+	value int64
+}
+
+const (
+	valueUnknown = iota
+	valueConst
+	valueRbp
+	valueRsp
+)
+
+// auditCalls walks every function linearly, tracking constant and
+// stack-relative argument registers up to calls of printf- and read-like
+// imports.
+func (e *ELFer) auditCalls() []Finding {
+	callees := make(map[uint64]string)
+	for name, addr := range e.plt {
+		callees[addr] = name
+	}
+	for name, entry := range e.got {
+		callees[entry.Addr] = name
+	}
+
+	var findings []Finding
+	for _, f := range e.Functions() {
+		code, ok := e.read(f.Start-e.base, int(f.End-f.Start))
+		if !ok {
+			continue
+		}
+
+		regs := make(map[x86asm.Reg]auditValue)
+		frame, pushes := int64(0), int64(0)
+		hasRbp, prologue := false, true
+		for off := 0; off < len(code); {
+			pc := f.Start + uint64(off)
+			if isEndbr(code[off:]) {
+				off += 4
+				continue
+			}
+			inst, err := x86asm.Decode(code[off:], 64)
+			if err != nil {
+				off++
+				continue
+			}
+			off += inst.Len
+			next := pc + uint64(inst.Len)
+			reg, _ := inst.Args[0].(x86asm.Reg)
+			dst, full := X86Reg(reg, 64)
+
+			if strings.HasPrefix(inst.Op.String(), "J") {
+				prologue = false
+			}
+			switch inst.Op {
+			case x86asm.PUSH:
+				if prologue {
+					pushes++
+				}
+				continue
+			case x86asm.SUB:
+				if imm, ok := inst.Args[1].(x86asm.Imm); ok && dst == x86asm.RSP && prologue {
+					frame += int64(imm)
+					continue
+				}
+			case x86asm.MOV:
+				if src, ok := inst.Args[1].(x86asm.Reg); ok && dst == x86asm.RBP && src == x86asm.RSP {
+					hasRbp = true
+					continue
+				}
+			case x86asm.CALL:
+				prologue = false
+				if name := e.callee(inst, next, callees); name != "" {
+					findings = append(findings, e.checkCall(pc, f.Name, name, regs, frame, pushes*8, hasRbp)...)
+				}
+				for _, r := range []x86asm.Reg{x86asm.RAX, x86asm.RCX, x86asm.RDX, x86asm.RSI, x86asm.RDI, x86asm.R8, x86asm.R9, x86asm.R10, x86asm.R11} {
+					delete(regs, r)
+				}
+				continue
+			}
+
+			if dst == 0 {
+				continue
+			}
+			if !full {
+				regs[dst] = auditValue{}
+				continue
+			}
+			regs[dst] = e.auditSource(inst, next, regs)
+		}
+	}
+	return findings
+}
+
+func (e *ELFer) auditSource(inst x86asm.Inst, next uint64, regs map[x86asm.Reg]auditValue) auditValue {
+	switch inst.Op {
+	case x86asm.XOR:
+		if inst.Args[0] == inst.Args[1] {
+			return auditValue{kind: valueConst}
+		}
+	case x86asm.MOV:
+		switch src := inst.Args[1].(type) {
+		case x86asm.Imm:
+			return auditValue{kind: valueConst, value: int64(src)}
+		case x86asm.Reg:
+			if r, full := X86Reg(src, 64); r == x86asm.RSP {
+				return auditValue{kind: valueRsp}
+			} else if full {
+				return regs[r]
+			}
+		}
+	case x86asm.LEA:
+		m := inst.Args[1].(x86asm.Mem)
+		if m.Index != 0 {
+			break
+		}
+		switch m.Base {
+		case x86asm.RIP:
+			return auditValue{kind: valueConst, value: int64(next) + X86Disp(m)}
+		case x86asm.RBP:
+			return auditValue{kind: valueRbp, value: X86Disp(m)}
+		case x86asm.RSP:
+			return auditValue{kind: valueRsp, value: X86Disp(m)}
+		}
+	}
+	return auditValue{}
+}
+
+func (e *ELFer) callee(inst x86asm.Inst, next uint64, callees map[uint64]string) string {
+	switch a := inst.Args[0].(type) {
+	case x86asm.Rel:
+		return callees[next+uint64(a)]
+	case x86asm.Mem:
+		if a.Base == x86asm.RIP && a.Index == 0 {
+			return callees[next+uint64(X86Disp(a))]
+		}
+	}
+	return ""
+}
+
+func (e *ELFer) checkCall(pc uint64, caller string, name string, regs map[x86asm.Reg]auditValue, frame int64, saved int64, hasRbp bool) []Finding {
+	var findings []Finding
+	if index, ok := formatArgs[name]; ok {
+		v := regs[sysvArgs[index]]
+		if v.kind != valueConst || e.writable(uint64(v.value)) {
+			findings = append(findings, Finding{
+				Kind:   AuditFormat,
+				Addr:   pc,
+				Detail: fmt.Sprintf("%s in %s is called with a non-constant format", name, caller),
+			})
+		}
+	}
+
+	if args, ok := readArgs[name]; ok {
+		buf, size := regs[sysvArgs[args[0]]], regs[sysvArgs[args[1]]]
+		if size.kind != valueConst {
+			return findings
+		}
+		var limit, ret int64
+		var where string
+		switch {
+		case buf.kind == valueRbp && hasRbp && buf.value < 0:
+			limit, ret, where = -buf.value, -buf.value+8, fmt.Sprintf("rbp-0x%x", -buf.value)
+		case buf.kind == valueRsp && frame > buf.value && buf.value >= 0:
+			limit, ret, where = frame-buf.value, frame+saved-buf.value, fmt.Sprintf("rsp+0x%x", buf.value)
+		default:
+			return findings
+		}
+		if size.value > limit {
+			findings = append(findings, Finding{
+				Kind:   AuditOverflow,
+				Addr:   pc,
+				Detail: fmt.Sprintf("%s in %s reads 0x%x bytes into a 0x%x byte buffer at %s (return address at +0x%x)", name, caller, size.value, limit, where, ret),
+			})
+		}
+	}
+	return findings
+}
+
+func (e *ELFer) auditFuncPtrs() []Finding {
+	var findings []Finding
+	names := make([]string, 0, len(e.got))
+	for name := range e.got {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return e.got[names[i]].Addr < e.got[names[j]].Addr })
+
+	seen := make(map[uint64]bool)
+	for _, name := range names {
+		entry := e.got[name]
+		if entry.Kind == RelocCopy || !e.writable(entry.Addr) {
+			continue
+		}
+		seen[entry.Addr] = true
+		findings = append(findings, Finding{
+			Kind:   AuditFuncPtr,
+			Addr:   entry.Addr,
+			Detail: fmt.Sprintf("GOT entry of %s (%s) is writable", name, entry.Reloc),
+		})
+	}
+
+	starts := make(map[uint64]string)
+	for _, f := range e.Functions() {
+		starts[f.Start-e.base] = f.Name
+	}
+	relative := make(map[uint64]uint64)
+	for _, rel := range e.relocs {
+		if rel.Symbol == "" && rel.Addend != 0 {
+			relative[rel.Offset] = uint64(rel.Addend)
+		}
+	}
+
+	word := uint64(e.Bits() / 8)
+	for _, prog := range e.file.Progs {
+		if prog.Type != elf.PT_LOAD || prog.Flags&elf.PF_W == 0 {
+			continue
+		}
+		for vaddr := prog.Vaddr; vaddr+word <= prog.Vaddr+prog.Filesz; vaddr += word {
+			if seen[e.base+vaddr] || !e.writable(e.base+vaddr) {
+				continue
+			}
+			value := e.readWord(vaddr)
+			if value == 0 {
+				value = relative[vaddr]
+			}
+			if name, ok := starts[value]; ok {
+				findings = append(findings, Finding{
+					Kind:   AuditFuncPtr,
+					Addr:   e.base + vaddr,
+					Detail: fmt.Sprintf("writable pointer to %s", name),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func (e *ELFer) auditSegments() []Finding {
+	var findings []Finding
+	for _, prog := range e.file.Progs {
+		switch {
+		case prog.Type == elf.PT_LOAD && prog.Flags&elf.PF_W != 0 && prog.Flags&elf.PF_X != 0:
+			findings = append(findings, Finding{
+				Kind:   AuditRWX,
+				Addr:   e.base + prog.Vaddr,
+				Detail: fmt.Sprintf("segment of 0x%x bytes is writable and executable", prog.Memsz),
+			})
+		case prog.Type == elf.PT_GNU_STACK && prog.Flags&elf.PF_X != 0:
+			findings = append(findings, Finding{Kind: AuditRWX, Detail: "stack is executable"})
+		}
+	}
+	return findings
+}
+
+// writable reports whether addr stays writable after relocation, i.e. it is
+// in a writable PT_LOAD and outside PT_GNU_RELRO.
+func (e *ELFer) writable(addr uint64) bool {
+	vaddr := addr - e.base
+	prog := e.loadSegment(vaddr)
+	if prog == nil || prog.Flags&elf.PF_W == 0 {
+		return false
+	}
+	for _, p := range e.file.Progs {
+		if p.Type == elf.PT_GNU_RELRO && vaddr >= p.Vaddr && vaddr < p.Vaddr+p.Memsz {
+			return false
+		}
+	}
+	return true
+}
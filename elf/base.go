@@ -0,0 +1,62 @@
+package elf
+
+import (
+	"debug/elf"
+	"pwner/utils"
+)
+
+func (e *ELFer) BaseFromLeak(symbol string, leak uint64) uint64 {
+	offset, exists := e.rawSym[symbol]
+	if !exists {
+		utils.Fatal("symbol '%s' not found", symbol)
+	}
+	return e.BaseFromOffset(leak, offset)
+}
+
+func (e *ELFer) BaseFromOffset(leak uint64, offset uint64) uint64 {
+	if leak < offset {
+		utils.Fatal("leak 0x%x is below offset 0x%x", leak, offset)
+	}
+
+	base := leak - offset
+	if base&0xfff != 0 {
+		utils.Fatal("base 0x%x is not page aligned (leak 0x%x, offset 0x%x)", base, leak, offset)
+	}
+	if kind, ok := e.plausibleBase(base); !ok {
+		utils.Warn("base 0x%x does not look like a %s base", base, kind)
+	}
+
+	e.Base(base)
+	utils.Info("base: 0x%x", base)
+	return base
+}
+
+func (e *ELFer) plausibleBase(base uint64) (string, bool) {
+	if e.file.Type == elf.ET_EXEC {
+		return "non-PIE", base == 0
+	}
+
+	library := false
+	if soname, err := e.file.DynString(elf.DT_SONAME); err == nil && len(soname) > 0 {
+		library = true
+	}
+
+	switch e.file.Machine {
+	case elf.EM_X86_64:
+		if library {
+			return "library", base>>40 == 0x7f
+		}
+		return "PIE", base>>40 == 0x55 || base>>40 == 0x56
+	case elf.EM_386:
+		if library {
+			return "library", base>>24 == 0xf7
+		}
+		return "PIE", base>>24 == 0x56 || base>>24 == 0x57
+	case elf.EM_AARCH64:
+		if library {
+			return "library", base>>32 == 0xffff
+		}
+		return "PIE", base>>32 == 0xaaaa || base>>32 == 0xaaab
+	}
+	return "", true
+}
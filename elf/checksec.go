@@ -0,0 +1,230 @@
+package elf
+
+import (
+	"debug/elf"
+	"fmt"
+	"pwner/utils"
+	"sort"
+	"strings"
+)
+
+const (
+	RelroNone    = "No RELRO"
+	RelroPartial = "Partial RELRO"
+	RelroFull    = "Full RELRO"
+)
+
+const (
+	ntGnuPropertyType0       = 5
+	gnuPropertyX86Feature1   = 0xc0000002
+	gnuPropertyX86FeatureIBT = 1 << 0
+	gnuPropertyX86FeatureSHS = 1 << 1
+)
+
+type Security struct {
+	RELRO     string
+	Canary    bool
+	NX        bool
+	PIE       bool
+	Fortify   bool
+	Fortified []string
+	RPATH     []string
+	RUNPATH   []string
+	IBT       bool
+	SHSTK     bool
+	Stripped  bool
+}
+
+func (e *ELFer) Checksec() *Security {
+	s := &Security{
+		RELRO:    e.relro(),
+		PIE:      e.file.Type == elf.ET_DYN,
+		Stripped: e.file.Section(".symtab") == nil,
+	}
+
+	for _, prog := range e.file.Progs {
+		if prog.Type == elf.PT_GNU_STACK {
+			s.NX = prog.Flags&elf.PF_X == 0
+		}
+	}
+
+	names := e.importedSymbols()
+	for name := range e.rawSym {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		switch {
+		case name == "__stack_chk_fail" || name == "__stack_chk_guard" || name == "__intel_security_cookie":
+			s.Canary = true
+		case strings.HasPrefix(name, "__") && strings.HasSuffix(name, "_chk"):
+			s.Fortified = append(s.Fortified, strings.TrimSuffix(strings.TrimPrefix(name, "__"), "_chk"))
+		}
+	}
+	s.Fortified = uniqueSorted(s.Fortified)
+	s.Fortify = len(s.Fortified) > 0
+
+	if rpath, err := e.file.DynString(elf.DT_RPATH); err == nil {
+		s.RPATH = rpath
+	}
+	if runpath, err := e.file.DynString(elf.DT_RUNPATH); err == nil {
+		s.RUNPATH = runpath
+	}
+
+	features := e.x86Features()
+	s.IBT = features&gnuPropertyX86FeatureIBT != 0
+	s.SHSTK = features&gnuPropertyX86FeatureSHS != 0
+
+	return s
+}
+
+func (e *ELFer) relro() string {
+	hasRelro := false
+	for _, prog := range e.file.Progs {
+		if prog.Type == elf.PT_GNU_RELRO {
+			hasRelro = true
+		}
+	}
+	if !hasRelro {
+		return RelroNone
+	}
+
+	if vals, err := e.file.DynValue(elf.DT_BIND_NOW); err == nil && len(vals) > 0 {
+		return RelroFull
+	}
+	if vals, err := e.file.DynValue(elf.DT_FLAGS); err == nil {
+		for _, v := range vals {
+			if v&uint64(elf.DF_BIND_NOW) != 0 {
+				return RelroFull
+			}
+		}
+	}
+	if vals, err := e.file.DynValue(elf.DT_FLAGS_1); err == nil {
+		for _, v := range vals {
+			if v&uint64(elf.DF_1_NOW) != 0 {
+				return RelroFull
+			}
+		}
+	}
+	return RelroPartial
+}
+
+func (e *ELFer) importedSymbols() []string {
+	symbols, err := e.file.DynamicSymbols()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, s := range symbols {
+		if s.Section == elf.SHN_UNDEF && s.Name != "" {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+func (e *ELFer) x86Features() uint32 {
+	align := 4
+	if e.file.Class == elf.ELFCLASS64 {
+		align = 8
+	}
+
+	var features uint32
+	for _, n := range e.notes() {
+		if n.typ != ntGnuPropertyType0 || n.name != "GNU" {
+			continue
+		}
+		desc := n.desc
+		for len(desc) >= 8 {
+			prType := e.file.ByteOrder.Uint32(desc[0:4])
+			prSize := int(e.file.ByteOrder.Uint32(desc[4:8]))
+			if 8+prSize > len(desc) {
+				break
+			}
+			if prType == gnuPropertyX86Feature1 && prSize >= 4 {
+				features |= e.file.ByteOrder.Uint32(desc[8:12])
+			}
+			next := 8 + alignUp(prSize, align)
+			if next > len(desc) {
+				break
+			}
+			desc = desc[next:]
+		}
+	}
+	return features
+}
+
+func (s *Security) String() string {
+	var b strings.Builder
+
+	line := func(key string, color string, value string) {
+		fmt.Fprintf(&b, "    %-10s%s%s%s\n", key+":", color, value, utils.ColorReset)
+	}
+
+	switch s.RELRO {
+	case RelroFull:
+		line("RELRO", utils.ColorGreen, s.RELRO)
+	case RelroPartial:
+		line("RELRO", utils.ColorYellow, s.RELRO)
+	default:
+		line("RELRO", utils.ColorRed, s.RELRO)
+	}
+
+	if s.Canary {
+		line("Stack", utils.ColorGreen, "Canary found")
+	} else {
+		line("Stack", utils.ColorRed, "No canary found")
+	}
+
+	if s.NX {
+		line("NX", utils.ColorGreen, "NX enabled")
+	} else {
+		line("NX", utils.ColorRed, "NX disabled")
+	}
+
+	if s.PIE {
+		line("PIE", utils.ColorGreen, "PIE enabled")
+	} else {
+		line("PIE", utils.ColorRed, "No PIE")
+	}
+
+	if s.Fortify {
+		line("FORTIFY", utils.ColorGreen, "Enabled ("+strings.Join(s.Fortified, ", ")+")")
+	}
+	if len(s.RPATH) > 0 {
+		line("RPATH", utils.ColorRed, strings.Join(s.RPATH, ":"))
+	}
+	if len(s.RUNPATH) > 0 {
+		line("RUNPATH", utils.ColorRed, strings.Join(s.RUNPATH, ":"))
+	}
+	if s.IBT {
+		line("IBT", utils.ColorGreen, "Enabled")
+	}
+	if s.SHSTK {
+		line("SHSTK", utils.ColorGreen, "Enabled")
+	}
+
+	if s.Stripped {
+		line("Stripped", utils.ColorGreen, "Yes")
+	} else {
+		line("Stripped", utils.ColorRed, "No")
+	}
+
+	return b.String()
+}
+
+func alignUp(n int, align int) int {
+	return (n + align - 1) &^ (align - 1)
+}
+
+func uniqueSorted(names []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
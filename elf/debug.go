@@ -0,0 +1,141 @@
+package elf
+
+import (
+	"bytes"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"pwner/utils"
+)
+
+const defaultDebugRoot = "/usr/lib/debug"
+
+func (e *ELFer) DebugFile() string {
+	path, _ := e.findDebug()
+	return path
+}
+
+func (e *ELFer) LoadDebug(path ...string) {
+	var p string
+	var d *ELFer
+	if len(path) > 0 {
+		p = path[0]
+		if d = e.openDebug(p); d == nil {
+			utils.Fatal("failed to open debug file '%s'", p)
+		}
+		if id, debugID := e.BuildID(), d.BuildID(); id != "" && debugID != "" && id != debugID {
+			utils.Fatal("build-id mismatch: %s has %s, expected %s", p, debugID, id)
+		}
+	} else if p, d = e.findDebug(); d == nil {
+		utils.Fatal("no separate debug file found")
+	}
+	e.mergeDebug(p, d)
+}
+
+// findDebug looks the debug file up by build-id, then by .gnu_debuglink, and
+// returns it already opened so that it is read only once.
+func (e *ELFer) findDebug() (string, *ELFer) {
+	root := utils.LoadConfig().DebugRoot
+	if root == "" {
+		root = defaultDebugRoot
+	}
+
+	if id := e.BuildID(); len(id) > 2 {
+		path := filepath.Join(root, ".build-id", id[:2], id[2:]+".debug")
+		if d := e.openDebug(path); d != nil && d.BuildID() == id {
+			return path, d
+		}
+	}
+
+	name, crc, ok := e.debugLink()
+	if !ok {
+		return "", nil
+	}
+	var candidates []string
+	if e.path != "" {
+		dir, err := filepath.Abs(filepath.Dir(e.path))
+		if err == nil {
+			candidates = append(candidates,
+				filepath.Join(dir, name),
+				filepath.Join(dir, ".debug", name),
+				filepath.Join(root, dir, name),
+			)
+		}
+	}
+	candidates = append(candidates, filepath.Join(root, name))
+
+	for _, path := range candidates {
+		if same(path, e.path) {
+			continue
+		}
+		if raw, err := os.ReadFile(path); err == nil && crc32.ChecksumIEEE(raw) == crc && isELF(raw) {
+			return path, parse(path, raw)
+		}
+	}
+	return "", nil
+}
+
+func (e *ELFer) openDebug(path string) *ELFer {
+	raw, err := os.ReadFile(path)
+	if err != nil || !isELF(raw) {
+		return nil
+	}
+	return parse(path, raw)
+}
+
+func isELF(raw []byte) bool {
+	return bytes.HasPrefix(raw, []byte("\x7fELF"))
+}
+
+func (e *ELFer) mergeDebug(path string, d *ELFer) {
+	for name, rawAddr := range d.rawSym {
+		if _, exists := e.rawSym[name]; !exists {
+			e.rawSym[name] = rawAddr
+			e.sym[name] = e.base + rawAddr
+		}
+	}
+
+	e.debug = d.file
+	e.rawGot = e.resolveGOT()
+	for name, entry := range e.rawGot {
+		entry.Addr += e.base
+		e.got[name] = entry
+	}
+
+	e.types = nil
+	utils.Debug("loaded debug symbols from '%s'", path)
+}
+
+// debugLink parses .gnu_debuglink: a NUL-terminated file name padded to four
+// bytes, followed by the CRC32 of the debug file.
+func (e *ELFer) debugLink() (string, uint32, bool) {
+	section := e.file.Section(".gnu_debuglink")
+	if section == nil {
+		return "", 0, false
+	}
+	data, err := section.Data()
+	if err != nil {
+		return "", 0, false
+	}
+	end := bytes.IndexByte(data, 0)
+	crcOff := alignUp(end+1, 4)
+	if end <= 0 || crcOff+4 > len(data) {
+		return "", 0, false
+	}
+	return string(data[:end]), e.file.ByteOrder.Uint32(data[crcOff:]), true
+}
+
+func same(a string, b string) bool {
+	if b == "" {
+		return false
+	}
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
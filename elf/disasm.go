@@ -0,0 +1,173 @@
+package elf
+
+import (
+	"debug/elf"
+	"fmt"
+	"pwner/utils"
+	"sort"
+	"strings"
+
+	"golang.org/x/arch/arm64/arm64asm"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+type symbolRange struct {
+	name  string
+	start uint64
+	end   uint64
+}
+
+func (e *ELFer) Disasm(addr uint64, n int) string {
+	return e.disasm(addr, e.Read(addr, n))
+}
+
+func (e *ELFer) DisasmFunction(name string) string {
+	start, end := e.FunctionRange(name)
+	return fmt.Sprintf("%016x <%s>:\n", start, name) + e.Disasm(start, int(end-start))
+}
+
+func (e *ELFer) FunctionRange(name string) (uint64, uint64) {
+	for _, r := range e.symbolRanges() {
+		if r.name == name && r.end > r.start {
+			return e.base + r.start, e.base + r.end
+		}
+	}
+	addr := e.Sym(name)
+	utils.Fatal("size of '%s' at 0x%x is unknown", name, addr)
+	return 0, 0
+}
+
+func (e *ELFer) disasm(addr uint64, code []byte) string {
+	symname := e.symbolizer()
+	var b strings.Builder
+	for off := 0; off < len(code); {
+		pc := addr + uint64(off)
+		text, size := e.decodeInst(code[off:], pc, symname)
+		if size > len(code)-off {
+			size = len(code) - off
+		}
+		fmt.Fprintf(&b, "%8x:  %-26s %s\n", pc, hexBytes(code[off:off+size]), text)
+		off += size
+	}
+	return b.String()
+}
+
+func (e *ELFer) decodeInst(code []byte, pc uint64, symname func(uint64) (string, uint64)) (string, int) {
+	switch e.file.Machine {
+	case elf.EM_X86_64, elf.EM_386:
+		mode := 64
+		if e.file.Machine == elf.EM_386 {
+			mode = 32
+		}
+		// x86asm predates CET and does not know endbr64/endbr32.
+		if isEndbr(code) {
+			if code[3] == 0xfa {
+				return "endbr64", 4
+			}
+			return "endbr32", 4
+		}
+		inst, err := x86asm.Decode(code, mode)
+		if err != nil {
+			return "(bad)", 1
+		}
+		text := x86asm.IntelSyntax(inst, pc, symname)
+		if inst.Op == x86asm.LEA {
+			text = strings.Replace(text, "ptr ", "", 1)
+		}
+		for _, arg := range inst.Args {
+			if m, ok := arg.(x86asm.Mem); ok && m.Base == x86asm.RIP && strings.Contains(text, "rip") {
+				text += fmt.Sprintf("  # %#x", pc+uint64(inst.Len)+uint64(X86Disp(m)))
+			}
+		}
+		return text, inst.Len
+	case elf.EM_AARCH64:
+		if len(code) < 4 {
+			return "(bad)", len(code)
+		}
+		inst, err := arm64asm.Decode(code)
+		if err != nil {
+			return "(bad)", 4
+		}
+		text := strings.TrimSpace(arm64asm.GNUSyntax(inst))
+		for _, arg := range inst.Args {
+			rel, ok := arg.(arm64asm.PCRel)
+			if !ok {
+				continue
+			}
+			target := pc + uint64(rel)
+			if inst.Op == arm64asm.ADRP {
+				// ADRP counts pages from the page containing pc.
+				target = pc&^0xfff + uint64(rel)
+			}
+			label := fmt.Sprintf("%#x", target)
+			if s, _ := symname(target); s != "" {
+				label += " <" + s + ">"
+			}
+			text = strings.Replace(text, rel.String(), label, 1)
+		}
+		return text, 4
+	}
+	utils.Fatal("disassembly is not supported for %s", e.file.Machine)
+	return "", 0
+}
+
+// symbolizer names addresses as "sym" or "sym+0x10" using PLT entries and
+// sized symbols; the returned base is always the address itself so that
+// x86asm prints the label for branch targets too.
+func (e *ELFer) symbolizer() func(uint64) (string, uint64) {
+	plts := make(map[uint64]string, len(e.plt))
+	for name, addr := range e.plt {
+		plts[addr] = name + "@plt"
+	}
+	ranges := e.symbolRanges()
+
+	return func(addr uint64) (string, uint64) {
+		if name, ok := plts[addr]; ok {
+			return name, addr
+		}
+		raw := addr - e.base
+		i := sort.Search(len(ranges), func(i int) bool { return ranges[i].start > raw }) - 1
+		for j := i; j >= 0 && j > i-16; j-- {
+			r := ranges[j]
+			if r.start == raw {
+				return r.name, addr
+			}
+			if raw < r.end {
+				return fmt.Sprintf("%s+0x%x", r.name, raw-r.start), addr
+			}
+		}
+		return "", 0
+	}
+}
+
+func (e *ELFer) symbolRanges() []symbolRange {
+	files := []*elf.File{e.file}
+	if e.debug != nil {
+		files = append(files, e.debug)
+	}
+
+	seen := make(map[string]bool)
+	var ranges []symbolRange
+	for _, file := range files {
+		symtab, _ := file.Symbols()
+		dynsym, _ := file.DynamicSymbols()
+		for _, s := range append(symtab, dynsym...) {
+			typ := elf.ST_TYPE(s.Info)
+			if s.Name == "" || s.Value == 0 || seen[s.Name] || (typ != elf.STT_FUNC && typ != elf.STT_OBJECT && typ != elf.STT_GNU_IFUNC) {
+				continue
+			}
+			seen[s.Name] = true
+			ranges = append(ranges, symbolRange{name: s.Name, start: s.Value, end: s.Value + s.Size})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	return ranges
+}
+
+func hexBytes(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,195 @@
+package elf
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"pwner/utils"
+	"strconv"
+	"strings"
+)
+
+type Field struct {
+	Name   string
+	Type   string
+	Offset uint64
+	Size   int64
+}
+
+func (e *ELFer) StructOffset(typ string, member string) uint64 {
+	t := e.dwarfType(typ)
+	var off uint64
+	for _, part := range strings.Split(member, ".") {
+		name, indexes := parseMember(part, member)
+
+		st, ok := resolveType(t).(*dwarf.StructType)
+		if !ok {
+			utils.Fatal("'%s' is not a struct or union", t)
+		}
+		field, fieldOff := findMember(st, name)
+		if field == nil {
+			utils.Fatal("'%s' has no member '%s'", st, name)
+		}
+		off += fieldOff
+		t = field.Type
+
+		for _, index := range indexes {
+			at, ok := resolveType(t).(*dwarf.ArrayType)
+			if !ok {
+				utils.Fatal("'%s' is not an array", member)
+			}
+			off += uint64(index) * uint64(at.Type.Size())
+			t = at.Type
+		}
+	}
+	return off
+}
+
+// findMember looks name up in st, descending into anonymous structs and
+// unions the same way structFields flattens them.
+func findMember(st *dwarf.StructType, name string) (*dwarf.StructField, uint64) {
+	for _, f := range st.Field {
+		if f.Name == name {
+			return f, uint64(f.ByteOffset)
+		}
+	}
+	for _, f := range st.Field {
+		if f.Name != "" {
+			continue
+		}
+		if nested, ok := resolveType(f.Type).(*dwarf.StructType); ok {
+			if field, off := findMember(nested, name); field != nil {
+				return field, uint64(f.ByteOffset) + off
+			}
+		}
+	}
+	return nil, 0
+}
+
+func (e *ELFer) SizeOf(typ string) int64 {
+	return e.dwarfType(typ).Size()
+}
+
+func (e *ELFer) FieldsOf(typ string) []Field {
+	st, ok := resolveType(e.dwarfType(typ)).(*dwarf.StructType)
+	if !ok {
+		utils.Fatal("'%s' is not a struct or union", typ)
+	}
+	return structFields(st, "", 0)
+}
+
+func structFields(st *dwarf.StructType, prefix string, base uint64) []Field {
+	var fields []Field
+	for _, f := range st.Field {
+		name := prefix + f.Name
+		off := base + uint64(f.ByteOffset)
+		fields = append(fields, Field{
+			Name:   name,
+			Type:   f.Type.String(),
+			Offset: off,
+			Size:   f.Type.Size(),
+		})
+		switch nested := resolveType(f.Type).(type) {
+		case *dwarf.StructType:
+			if f.Name == "" {
+				fields = append(fields[:len(fields)-1], structFields(nested, prefix, off)...)
+			} else {
+				fields = append(fields, structFields(nested, name+".", off)...)
+			}
+		case *dwarf.ArrayType:
+			elem, ok := resolveType(nested.Type).(*dwarf.StructType)
+			if !ok || nested.Count <= 0 {
+				continue
+			}
+			for i := int64(0); i < nested.Count; i++ {
+				elemOff := off + uint64(i)*uint64(elem.Size())
+				fields = append(fields, structFields(elem, fmt.Sprintf("%s[%d].", name, i), elemOff)...)
+			}
+		}
+	}
+	return fields
+}
+
+// parseMember splits "buf[2][3]" into its name and array indexes.
+func parseMember(part string, member string) (string, []int) {
+	name, rest, _ := strings.Cut(part, "[")
+	var indexes []int
+	for rest != "" {
+		index, tail, ok := strings.Cut(rest, "]")
+		n, err := strconv.ParseInt(index, 0, 64)
+		if !ok || err != nil {
+			utils.Fatal("invalid member path '%s'", member)
+		}
+		indexes = append(indexes, int(n))
+		rest = strings.TrimPrefix(tail, "[")
+	}
+	return name, indexes
+}
+
+func resolveType(t dwarf.Type) dwarf.Type {
+	for {
+		switch v := t.(type) {
+		case *dwarf.TypedefType:
+			t = v.Type
+		case *dwarf.QualType:
+			t = v.Type
+		default:
+			return t
+		}
+	}
+}
+
+func (e *ELFer) dwarfType(name string) dwarf.Type {
+	if e.types == nil {
+		e.types = e.loadTypes()
+	}
+	t, ok := e.types[name]
+	if !ok {
+		utils.Fatal("type '%s' not found in DWARF", name)
+	}
+	return t
+}
+
+func (e *ELFer) loadTypes() map[string]dwarf.Type {
+	file := e.file
+	if e.debug != nil {
+		file = e.debug
+	}
+	data, err := file.DWARF()
+	if err != nil {
+		utils.Fatal("failed to read DWARF: %v", err)
+	}
+
+	types := make(map[string]dwarf.Type)
+	r := data.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			utils.Fatal("failed to read DWARF: %v", err)
+		}
+		if entry == nil {
+			break
+		}
+		switch entry.Tag {
+		case dwarf.TagStructType, dwarf.TagUnionType, dwarf.TagTypedef,
+			dwarf.TagBaseType, dwarf.TagEnumerationType:
+		default:
+			continue
+		}
+		if entry.Val(dwarf.AttrName) == nil {
+			continue
+		}
+
+		t, err := data.Type(entry.Offset)
+		if err != nil {
+			continue
+		}
+		name := t.String()
+		if existing, exists := types[name]; exists {
+			if st, ok := existing.(*dwarf.StructType); !ok || !st.Incomplete {
+				continue
+			}
+		}
+		types[name] = t
+	}
+	return types
+}
@@ -0,0 +1,184 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/hex"
+	"path/filepath"
+	"pwner/utils"
+	"strings"
+)
+
+const ntGnuBuildID = 3
+
+var defaultLibraryPaths = map[elf.Machine][]string{
+	elf.EM_X86_64:  {"/lib/x86_64-linux-gnu", "/usr/lib/x86_64-linux-gnu", "/lib64", "/usr/lib64"},
+	elf.EM_386:     {"/lib/i386-linux-gnu", "/usr/lib/i386-linux-gnu", "/lib32", "/usr/lib32"},
+	elf.EM_AARCH64: {"/lib/aarch64-linux-gnu", "/usr/lib/aarch64-linux-gnu", "/lib64", "/usr/lib64"},
+	elf.EM_ARM:     {"/lib/arm-linux-gnueabihf", "/usr/lib/arm-linux-gnueabihf"},
+	elf.EM_RISCV:   {"/lib/riscv64-linux-gnu", "/usr/lib/riscv64-linux-gnu"},
+}
+
+type note struct {
+	name string
+	typ  uint32
+	desc []byte
+}
+
+func (e *ELFer) Needed() []string {
+	needed, err := e.file.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return nil
+	}
+	return needed
+}
+
+func (e *ELFer) Interpreter() string {
+	for _, prog := range e.file.Progs {
+		if prog.Type == elf.PT_INTERP {
+			data := e.raw[prog.Off : prog.Off+prog.Filesz]
+			if i := bytes.IndexByte(data, 0); i >= 0 {
+				data = data[:i]
+			}
+			return string(data)
+		}
+	}
+	return ""
+}
+
+func (e *ELFer) Runpath() []string {
+	for _, tag := range []elf.DynTag{elf.DT_RUNPATH, elf.DT_RPATH} {
+		values, err := e.file.DynString(tag)
+		if err != nil || len(values) == 0 {
+			continue
+		}
+		var paths []string
+		for _, value := range values {
+			paths = append(paths, strings.Split(value, ":")...)
+		}
+		return paths
+	}
+	return nil
+}
+
+func (e *ELFer) BuildID() string {
+	for _, n := range e.notes() {
+		if n.typ == ntGnuBuildID && n.name == "GNU" {
+			return hex.EncodeToString(n.desc)
+		}
+	}
+	return ""
+}
+
+func (e *ELFer) SymbolVersion(name string) string {
+	symbols, err := e.file.DynamicSymbols()
+	if err != nil {
+		utils.Fatal("failed to get dynamic symbols: %v", err)
+	}
+	for _, s := range symbols {
+		if s.Name == name {
+			return s.Version
+		}
+	}
+	utils.Fatal("symbol '%s' not found", name)
+	return ""
+}
+
+func (e *ELFer) Ldd(dirs ...string) map[string]string {
+	libs := make(map[string]string)
+	e.resolveNeeded(e.file, e.path, dirs, libs)
+	return libs
+}
+
+func (e *ELFer) resolveNeeded(file *elf.File, path string, dirs []string, libs map[string]string) {
+	needed, err := file.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return
+	}
+
+	var search []string
+	search = append(search, dirs...)
+	for _, tag := range []elf.DynTag{elf.DT_RUNPATH, elf.DT_RPATH} {
+		values, _ := file.DynString(tag)
+		for _, value := range values {
+			for _, dir := range strings.Split(value, ":") {
+				if path != "" {
+					dir = strings.ReplaceAll(dir, "$ORIGIN", filepath.Dir(path))
+					dir = strings.ReplaceAll(dir, "${ORIGIN}", filepath.Dir(path))
+				}
+				search = append(search, dir)
+			}
+		}
+	}
+	search = append(search, defaultLibraryPaths[e.file.Machine]...)
+	search = append(search, "/lib", "/usr/lib")
+
+	for _, name := range needed {
+		if _, done := libs[name]; done {
+			continue
+		}
+		libs[name] = ""
+
+		for _, dir := range search {
+			candidate := filepath.Join(dir, name)
+			if strings.Contains(name, "/") {
+				candidate = name
+			}
+			lib, err := elf.Open(candidate)
+			if err != nil {
+				continue
+			}
+			if lib.Machine != e.file.Machine || lib.Class != e.file.Class {
+				lib.Close()
+				continue
+			}
+
+			libs[name] = candidate
+			e.resolveNeeded(lib, candidate, dirs, libs)
+			lib.Close()
+			break
+		}
+	}
+}
+
+func (e *ELFer) notes() []note {
+	var notes []note
+	for _, prog := range e.file.Progs {
+		if prog.Type != elf.PT_NOTE || prog.Off+prog.Filesz > uint64(len(e.raw)) {
+			continue
+		}
+		align := int(prog.Align)
+		if align < 4 {
+			align = 4
+		}
+		notes = append(notes, e.parseNotes(e.raw[prog.Off:prog.Off+prog.Filesz], align)...)
+	}
+	return notes
+}
+
+func (e *ELFer) parseNotes(data []byte, align int) []note {
+	var notes []note
+	order := e.file.ByteOrder
+	for len(data) >= 12 {
+		nameSize := int(order.Uint32(data[0:4]))
+		descSize := int(order.Uint32(data[4:8]))
+		noteType := order.Uint32(data[8:12])
+		descOff := alignUp(12+nameSize, align)
+		if descOff+descSize > len(data) {
+			break
+		}
+
+		notes = append(notes, note{
+			name: strings.TrimRight(string(data[12:12+nameSize]), "\x00"),
+			typ:  noteType,
+			desc: data[descOff : descOff+descSize],
+		})
+
+		end := alignUp(descOff+descSize, align)
+		if end > len(data) {
+			break
+		}
+		data = data[end:]
+	}
+	return notes
+}
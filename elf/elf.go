@@ -1,7 +1,11 @@
 package elf
 
 import (
+	"bytes"
+	"debug/dwarf"
 	"debug/elf"
+	"fmt"
+	"os"
 	"pwner/tube"
 	"pwner/utils"
 	"sync"
@@ -10,22 +14,83 @@ import (
 type ELFer struct {
 	base   uint64
 	path   string
+	raw    []byte
+	file   *elf.File
 	sym    map[string]uint64
 	rawSym map[string]uint64
+	plt    map[string]uint64
+	rawPlt map[string]uint64
+	got    map[string]GOTEntry
+	rawGot map[string]GOTEntry
+	relocs []relocation
+	debug  *elf.File
+	types  map[string]dwarf.Type
+	dirty  bool
 }
 
 func ELF(path string) *ELFer {
-	file, err := elf.Open(path)
+	e := Load(path)
+	e.summary()
+	return e
+}
+
+func Load(path string) *ELFer {
+	if path == "" {
+		path = utils.LoadConfig().Binary
+		if path == "" {
+			utils.Fatal("no ELF path specified")
+		}
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		utils.Fatal("failed to open ELF: %v", err)
+	}
+
+	e := load(path, raw)
+	if e.file.Section(".symtab") == nil {
+		if debug, d := e.findDebug(); d != nil {
+			e.mergeDebug(debug, d)
+		}
+	}
+	return e
+}
+
+func load(path string, raw []byte) *ELFer {
+	e := parse(path, raw)
+
+	e.relocs = e.dynamicRelocations()
+
+	e.rawPlt = e.resolvePLT()
+	for name, rawAddr := range e.rawPlt {
+		e.plt[name] = rawAddr
+	}
+
+	e.rawGot = e.resolveGOT()
+	for name, entry := range e.rawGot {
+		e.got[name] = entry
+	}
+
+	return e
+}
+
+// parse reads the headers and symbols only, which is all a separate debug
+// file contributes.
+func parse(path string, raw []byte) *ELFer {
+	file, err := elf.NewFile(bytes.NewReader(raw))
 	if err != nil {
 		utils.Fatal("failed to open ELF: %v", err)
 	}
-	defer file.Close()
 
 	e := &ELFer{
 		base:   0,
 		path:   path,
+		raw:    raw,
+		file:   file,
 		sym:    make(map[string]uint64),
 		rawSym: make(map[string]uint64),
+		plt:    make(map[string]uint64),
+		got:    make(map[string]GOTEntry),
 	}
 
 	var symSymbols, dynSymbols []elf.Symbol
@@ -67,11 +132,46 @@ func ELF(path string) *ELFer {
 	return e
 }
 
+func (e *ELFer) summary() {
+	if utils.LogLevel > utils.LogInfo {
+		return
+	}
+	if e.path != "" {
+		utils.Info("'%s'", e.path)
+	} else {
+		utils.Info("<memory>")
+	}
+	fmt.Print(e.Checksec())
+}
+
+func Libc() *ELFer {
+	path := utils.LoadConfig().Libc
+	if path == "" {
+		utils.Fatal("no libc configured in %s", utils.ConfigName)
+	}
+	return ELF(path)
+}
+
 func (e *ELFer) Base(base uint64) {
 	e.base = base
 	for name, rawAddr := range e.rawSym {
 		e.sym[name] = e.base + rawAddr
 	}
+	for name, rawAddr := range e.rawPlt {
+		e.plt[name] = e.base + rawAddr
+	}
+	for name, entry := range e.rawGot {
+		entry.Addr += e.base
+		e.got[name] = entry
+	}
+}
+
+func (e *ELFer) Symbols() map[string]uint64 {
+	syms := make(map[string]uint64, len(e.sym))
+	for name, addr := range e.sym {
+		syms[name] = addr
+	}
+	return syms
 }
 
 func (e *ELFer) Sym(name string) uint64 {
@@ -83,8 +183,7 @@ func (e *ELFer) Sym(name string) uint64 {
 }
 
 func (e *ELFer) Plt(name string) uint64 {
-	pltName := name + "@plt"
-	if addr, exists := e.sym[pltName]; exists {
+	if addr, exists := e.plt[name]; exists {
 		return addr
 	}
 	utils.Fatal("PLT entry '%s' not found", name)
@@ -92,90 +191,36 @@ func (e *ELFer) Plt(name string) uint64 {
 }
 
 func (e *ELFer) Got(name string) uint64 {
-	file, err := elf.Open(e.path)
-	if err != nil {
-		utils.Fatal("failed to open ELF: %v", err)
-	}
-	defer file.Close()
-
-	gotSection := file.Section(".got.plt")
-	if gotSection == nil {
-		gotSection = file.Section(".got")
-	}
-	if gotSection == nil {
-		utils.Fatal("GOT section not found")
+	if entry, exists := e.got[name]; exists {
+		return entry.Addr
 	}
+	utils.Fatal("GOT entry '%s' not found", name)
+	return 0
+}
 
-	relPltSection := file.Section(".rela.plt")
-	if relPltSection == nil {
-		relPltSection = file.Section(".rel.plt")
-	}
-	if relPltSection == nil {
-		utils.Fatal("relocation section not found")
+func (e *ELFer) Process(arg ...string) *tube.Proc {
+	// A patched ELFer no longer matches the file at e.path.
+	if e.path != "" && !e.dirty {
+		return tube.Process(append([]string{e.path}, arg...))
 	}
+	// The running process keeps its image, so the file can go once it started.
+	path := e.executable()
+	defer os.Remove(path)
+	return tube.Process(append([]string{path}, arg...))
+}
 
-	dynSymbols, err := file.DynamicSymbols()
+func (e *ELFer) executable() string {
+	f, err := os.CreateTemp("", "pwner-elf-*")
 	if err != nil {
-		utils.Fatal("failed to get dynamic symbols: %v", err)
+		utils.Fatal("failed to create executable: %v", err)
 	}
+	defer f.Close()
 
-	data, _ := relPltSection.Data()
-	var entrySize int
-	if file.Class == elf.ELFCLASS64 {
-		if relPltSection.Type == elf.SHT_RELA {
-			entrySize = 24
-		} else {
-			entrySize = 16
-		}
-	} else {
-		if relPltSection.Type == elf.SHT_RELA {
-			entrySize = 12
-		} else {
-			entrySize = 8
-		}
+	if _, err := f.Write(e.raw); err != nil {
+		utils.Fatal("failed to write executable: %v", err)
 	}
-
-	entryCount := len(data) / entrySize
-
-	for i := 0; i < entryCount; i++ {
-		entryData := data[i*entrySize : (i+1)*entrySize]
-
-		var offset uint64
-		var symIndex uint32
-
-		if file.Class == elf.ELFCLASS64 {
-			offset = uint64(entryData[0]) | uint64(entryData[1])<<8 |
-				uint64(entryData[2])<<16 | uint64(entryData[3])<<24 |
-				uint64(entryData[4])<<32 | uint64(entryData[5])<<40 |
-				uint64(entryData[6])<<48 | uint64(entryData[7])<<56
-
-			info := uint64(entryData[8]) | uint64(entryData[9])<<8 |
-				uint64(entryData[10])<<16 | uint64(entryData[11])<<24 |
-				uint64(entryData[12])<<32 | uint64(entryData[13])<<40 |
-				uint64(entryData[14])<<48 | uint64(entryData[15])<<56
-
-			symIndex = uint32(info >> 32)
-		} else {
-			offset = uint64(entryData[0]) | uint64(entryData[1])<<8 |
-				uint64(entryData[2])<<16 | uint64(entryData[3])<<24
-
-			info := uint32(entryData[4]) | uint32(entryData[5])<<8 |
-				uint32(entryData[6])<<16 | uint32(entryData[7])<<24
-
-			symIndex = info >> 8
-		}
-
-		if symIndex < uint32(len(dynSymbols)) {
-			if dynSymbols[symIndex].Name == name {
-				return e.base + offset
-			}
-		}
+	if err := f.Chmod(0755); err != nil {
+		utils.Fatal("failed to chmod executable: %v", err)
 	}
-
-	utils.Fatal("GOT entry '%s' not found", name)
-	return 0
-}
-
-func (e *ELFer) Process(arg ...string) *tube.Proc {
-	return tube.Process(append([]string{e.path}, arg...))
+	return f.Name()
 }
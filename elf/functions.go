@@ -0,0 +1,243 @@
+package elf
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+type Function struct {
+	Name  string
+	Start uint64
+	End   uint64
+}
+
+func (e *ELFer) Functions() []Function {
+	var funcs []Function
+	covered := make(map[uint64]bool)
+	for _, r := range e.symbolRanges() {
+		if r.end == r.start || !e.isCode(r.start) || covered[r.start] {
+			continue
+		}
+		covered[r.start] = true
+		funcs = append(funcs, Function{Name: r.name, Start: e.base + r.start, End: e.base + r.end})
+	}
+	for _, r := range e.frameRanges() {
+		if covered[r.start] {
+			continue
+		}
+		covered[r.start] = true
+		funcs = append(funcs, Function{Name: fmt.Sprintf("sub_%x", r.start), Start: e.base + r.start, End: e.base + r.end})
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Start < funcs[j].Start })
+	return funcs
+}
+
+func (e *ELFer) FunctionAt(addr uint64) *Function {
+	return functionAt(e.Functions(), addr)
+}
+
+func functionAt(funcs []Function, addr uint64) *Function {
+	i := sort.Search(len(funcs), func(i int) bool { return funcs[i].Start > addr }) - 1
+	for j := i; j >= 0 && j > i-16; j-- {
+		if addr < funcs[j].End {
+			return &funcs[j]
+		}
+	}
+	return nil
+}
+
+func (e *ELFer) isCode(vaddr uint64) bool {
+	prog := e.loadSegment(vaddr)
+	return prog != nil && prog.Flags&elf.PF_X != 0
+}
+
+// frameRanges collects the pc ranges of every FDE in .eh_frame, which
+// survives stripping and gives function boundaries for stripped binaries.
+func (e *ELFer) frameRanges() []symbolRange {
+	section := e.file.Section(".eh_frame")
+	if section == nil || section.Type == elf.SHT_NOBITS {
+		return nil
+	}
+	data, err := section.Data()
+	if err != nil {
+		return nil
+	}
+
+	order := e.file.ByteOrder
+	encodings := make(map[int]byte)
+	var ranges []symbolRange
+	for off := 0; off+4 <= len(data); {
+		length := uint64(order.Uint32(data[off:]))
+		header := 4
+		if length == 0xffffffff {
+			if off+12 > len(data) {
+				break
+			}
+			length = order.Uint64(data[off+4:])
+			header = 12
+		}
+		if length == 0 {
+			break
+		}
+		start := off + header
+		end := start + int(length)
+		if end > len(data) || start+4 > end {
+			break
+		}
+		record := data[start:end]
+
+		id := order.Uint32(record)
+		if id == 0 {
+			encodings[off] = e.cieEncoding(record[4:])
+		} else if enc, ok := encodings[start-int(id)]; ok {
+			pc := section.Addr + uint64(start) + 4
+			begin, n := e.readEncoded(record[4:], enc, pc)
+			size, _ := e.readEncoded(record[4+n:], enc&0x0f, 0)
+			if begin != 0 && size != 0 {
+				ranges = append(ranges, symbolRange{name: fmt.Sprintf("sub_%x", begin), start: begin, end: begin + size})
+			}
+		}
+		off = end
+	}
+	return ranges
+}
+
+// cieEncoding returns the FDE pointer encoding from a CIE's "zR" augmentation.
+func (e *ELFer) cieEncoding(data []byte) byte {
+	if len(data) == 0 {
+		return 0
+	}
+	version := data[0]
+	data = data[1:]
+	aug := 0
+	for aug < len(data) && data[aug] != 0 {
+		aug++
+	}
+	if aug >= len(data) {
+		return 0
+	}
+	augmentation := string(data[:aug])
+	data = data[aug+1:]
+
+	_, n := uleb(data) // code alignment
+	data = data[n:]
+	_, n = uleb(data) // data alignment
+	data = data[n:]
+	if len(data) == 0 {
+		return 0
+	}
+	if version == 1 {
+		data = data[1:]
+	} else {
+		_, n = uleb(data)
+		data = data[n:]
+	}
+	if len(augmentation) == 0 || augmentation[0] != 'z' {
+		return 0
+	}
+	_, n = uleb(data)
+	data = data[n:]
+
+	for _, c := range augmentation[1:] {
+		if len(data) == 0 {
+			return 0
+		}
+		switch c {
+		case 'R':
+			return data[0]
+		case 'L':
+			data = data[1:]
+		case 'P':
+			_, n := e.readEncoded(data[1:], data[0], 0)
+			if 1+n > len(data) {
+				return 0
+			}
+			data = data[1+n:]
+		}
+	}
+	return 0
+}
+
+func (e *ELFer) readEncoded(data []byte, enc byte, pc uint64) (uint64, int) {
+	order := e.file.ByteOrder
+	var v uint64
+	var n int
+	if size := encodedSize(enc, e.file.Class); size > len(data) {
+		return 0, len(data)
+	}
+	switch enc & 0x0f {
+	case 0x00:
+		if e.file.Class == elf.ELFCLASS32 {
+			v, n = uint64(order.Uint32(data)), 4
+		} else {
+			v, n = order.Uint64(data), 8
+		}
+	case 0x01:
+		v, n = uleb(data)
+	case 0x02:
+		v, n = uint64(order.Uint16(data)), 2
+	case 0x03:
+		v, n = uint64(order.Uint32(data)), 4
+	case 0x04, 0x0c:
+		v, n = order.Uint64(data), 8
+	case 0x09:
+		s, m := sleb(data)
+		v, n = uint64(s), m
+	case 0x0a:
+		v, n = uint64(int64(int16(order.Uint16(data)))), 2
+	case 0x0b:
+		v, n = uint64(int64(int32(order.Uint32(data)))), 4
+	default:
+		return 0, 0
+	}
+	if enc&0x70 == 0x10 {
+		v += pc
+	}
+	if e.file.Class == elf.ELFCLASS32 {
+		v &= 0xffffffff
+	}
+	return v, n
+}
+
+func encodedSize(enc byte, class elf.Class) int {
+	switch enc & 0x0f {
+	case 0x00:
+		if class == elf.ELFCLASS32 {
+			return 4
+		}
+		return 8
+	case 0x02, 0x0a:
+		return 2
+	case 0x03, 0x0b:
+		return 4
+	case 0x04, 0x0c:
+		return 8
+	}
+	return 1
+}
+
+func uleb(data []byte) (uint64, int) {
+	v, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, len(data)
+	}
+	return v, n
+}
+
+func sleb(data []byte) (int64, int) {
+	var v int64
+	var shift uint
+	for i, b := range data {
+		v |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v, i + 1
+		}
+	}
+	return v, len(data)
+}
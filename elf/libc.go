@@ -0,0 +1,111 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type GlibcVersion struct {
+	Major           int
+	Minor           int
+	Patch           int
+	Banner          string
+	Tcache          bool
+	SafeLinking     bool
+	MallocHooks     bool
+	StartMainReturn uint64
+}
+
+var glibcVersionPattern = regexp.MustCompile(`version (\d+)\.(\d+)(?:\.(\d+))?`)
+
+func (e *ELFer) LibcVersion() *GlibcVersion {
+	var banner string
+	e.SearchIter("GNU C Library")(func(addr uint64) bool {
+		banner = strings.SplitN(e.CString(addr), "\n", 2)[0]
+		return false
+	})
+	m := glibcVersionPattern.FindStringSubmatch(banner)
+	if m == nil {
+		return nil
+	}
+
+	v := &GlibcVersion{Banner: banner}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+
+	v.Tcache = v.AtLeast(2, 26)
+	v.SafeLinking = v.AtLeast(2, 32)
+	v.MallocHooks = !v.AtLeast(2, 34)
+	v.StartMainReturn = e.startMainReturn()
+
+	return v
+}
+
+func (v *GlibcVersion) AtLeast(major int, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+func (v *GlibcVersion) String() string {
+	if v.Patch != 0 {
+		return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	}
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+func (e *ELFer) startMainReturn() uint64 {
+	if e.file.Machine != elf.EM_X86_64 {
+		return 0
+	}
+	start, ok := e.rawSym["__libc_start_main"]
+	if !ok {
+		return 0
+	}
+
+	prog := e.loadSegment(start)
+	if prog == nil {
+		return 0
+	}
+
+	const window = 0x400
+	from, to := start-window, start+window
+	if start < prog.Vaddr+window {
+		from = prog.Vaddr
+	}
+	if to > prog.Vaddr+prog.Filesz {
+		to = prog.Vaddr + prog.Filesz
+	}
+	data, ok := e.read(from, int(to-from))
+	if !ok {
+		return 0
+	}
+
+	// call rax; mov edi, eax; call exit
+	pattern := []byte{0xff, 0xd0, 0x89, 0xc7, 0xe8}
+	best := uint64(0)
+	for pos := 0; ; pos++ {
+		i := bytes.Index(data[pos:], pattern)
+		if i < 0 {
+			break
+		}
+		pos += i
+		ret := from + uint64(pos) + 2
+		if best == 0 || distance(ret, start) < distance(best, start) {
+			best = ret
+		}
+	}
+	return best
+}
+
+func distance(a uint64, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
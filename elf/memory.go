@@ -0,0 +1,164 @@
+package elf
+
+import (
+	"debug/elf"
+	"pwner/utils"
+)
+
+type Section struct {
+	Name   string
+	Addr   uint64
+	Offset uint64
+	Size   uint64
+	Flags  elf.SectionFlag
+	Data   []byte
+}
+
+type Segment struct {
+	Type   elf.ProgType
+	Flags  elf.ProgFlag
+	Addr   uint64
+	Offset uint64
+	Filesz uint64
+	Memsz  uint64
+	Align  uint64
+}
+
+func (e *ELFer) Section(name string) *Section {
+	section := e.file.Section(name)
+	if section == nil {
+		utils.Fatal("section '%s' not found", name)
+	}
+
+	var data []byte
+	if section.Type != elf.SHT_NOBITS {
+		var err error
+		data, err = section.Data()
+		if err != nil {
+			utils.Fatal("failed to read section '%s': %v", name, err)
+		}
+	}
+
+	return &Section{
+		Name:   section.Name,
+		Addr:   e.base + section.Addr,
+		Offset: section.Offset,
+		Size:   section.Size,
+		Flags:  section.Flags,
+		Data:   data,
+	}
+}
+
+func (e *ELFer) Segments() []Segment {
+	var segments []Segment
+	for _, prog := range e.file.Progs {
+		segments = append(segments, Segment{
+			Type:   prog.Type,
+			Flags:  prog.Flags,
+			Addr:   e.base + prog.Vaddr,
+			Offset: prog.Off,
+			Filesz: prog.Filesz,
+			Memsz:  prog.Memsz,
+			Align:  prog.Align,
+		})
+	}
+	return segments
+}
+
+func (e *ELFer) BSS(offset uint64) uint64 {
+	section := e.file.Section(".bss")
+	if section == nil {
+		utils.Fatal("section '.bss' not found")
+	}
+	return e.base + section.Addr + offset
+}
+
+func (e *ELFer) VaddrToOffset(addr uint64) uint64 {
+	vaddr := addr - e.base
+	for _, prog := range e.file.Progs {
+		if prog.Type == elf.PT_LOAD && vaddr >= prog.Vaddr && vaddr < prog.Vaddr+prog.Filesz {
+			return prog.Off + vaddr - prog.Vaddr
+		}
+	}
+	utils.Fatal("address 0x%x is not backed by the file", addr)
+	return 0
+}
+
+func (e *ELFer) OffsetToVaddr(offset uint64) uint64 {
+	for _, prog := range e.file.Progs {
+		if prog.Type == elf.PT_LOAD && offset >= prog.Off && offset < prog.Off+prog.Filesz {
+			return e.base + prog.Vaddr + offset - prog.Off
+		}
+	}
+	utils.Fatal("offset 0x%x is not mapped", offset)
+	return 0
+}
+
+func (e *ELFer) Read(addr uint64, n int) []byte {
+	data, ok := e.read(addr-e.base, n)
+	if !ok {
+		utils.Fatal("address 0x%x-0x%x is not mapped", addr, addr+uint64(n))
+	}
+	return data
+}
+
+func (e *ELFer) U64(addr uint64) uint64 {
+	return e.file.ByteOrder.Uint64(e.Read(addr, 8))
+}
+
+func (e *ELFer) U32(addr uint64) uint32 {
+	return e.file.ByteOrder.Uint32(e.Read(addr, 4))
+}
+
+func (e *ELFer) CString(addr uint64) string {
+	var buf []byte
+	for {
+		b, ok := e.read(addr-e.base+uint64(len(buf)), 1)
+		if !ok {
+			utils.Fatal("unterminated string at 0x%x", addr)
+		}
+		if b[0] == 0 {
+			return string(buf)
+		}
+		buf = append(buf, b[0])
+	}
+}
+
+func (e *ELFer) read(vaddr uint64, n int) ([]byte, bool) {
+	buf := make([]byte, 0, n)
+	for len(buf) < n {
+		cur := vaddr + uint64(len(buf))
+		prog := e.loadSegment(cur)
+		if prog == nil {
+			return nil, false
+		}
+
+		end := prog.Vaddr + prog.Memsz
+		if want := cur + uint64(n-len(buf)); want < end {
+			end = want
+		}
+
+		fileEnd := prog.Vaddr + prog.Filesz
+		if cur < fileEnd {
+			stop := end
+			if fileEnd < stop {
+				stop = fileEnd
+			}
+			buf = append(buf, e.raw[prog.Off+cur-prog.Vaddr:prog.Off+stop-prog.Vaddr]...)
+			cur = stop
+		}
+		if cur < end {
+			buf = append(buf, make([]byte, end-cur)...)
+		}
+	}
+	return buf, true
+}
+
+func (e *ELFer) loadSegment(vaddr uint64) *elf.Prog {
+	for _, prog := range e.file.Progs {
+		if prog.Type == elf.PT_LOAD && vaddr >= prog.Vaddr && vaddr < prog.Vaddr+prog.Memsz {
+			return prog
+		}
+	}
+	return nil
+}
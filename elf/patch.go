@@ -0,0 +1,39 @@
+package elf
+
+import (
+	"os"
+	"pwner/asm"
+	"pwner/utils"
+)
+
+func (e *ELFer) Write(addr uint64, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	start := e.VaddrToOffset(addr)
+	end := e.VaddrToOffset(addr + uint64(len(data)) - 1)
+	if end-start != uint64(len(data))-1 {
+		utils.Fatal("patch at 0x%x crosses a segment boundary", addr)
+	}
+	copy(e.raw[start:], data)
+	e.dirty = true
+}
+
+func (e *ELFer) Asm(addr uint64, code string) []byte {
+	data := asm.Asm(code, e.Arch(), addr)
+	e.Write(addr, data)
+	return data
+}
+
+func (e *ELFer) Save(path string) {
+	mode := os.FileMode(0755)
+	if info, err := os.Stat(e.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, e.raw, mode); err != nil {
+		utils.Fatal("failed to save ELF: %v", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		utils.Fatal("failed to save ELF: %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"pwner/utils"
+	"strings"
+)
+
+func (e *ELFer) SetInterpreter(path string) {
+	e.dirty = true
+	progs := e.readProgs()
+	index := -1
+	for i, prog := range progs {
+		if elf.ProgType(prog.Type) == elf.PT_INTERP {
+			index = i
+		}
+	}
+	if index < 0 {
+		utils.Fatal("PT_INTERP not found")
+	}
+
+	interp := append([]byte(path), 0)
+	if uint64(len(interp)) <= progs[index].Filesz {
+		off := progs[index].Off
+		copy(e.raw[off:off+progs[index].Filesz], make([]byte, progs[index].Filesz))
+		copy(e.raw[off:], interp)
+		return
+	}
+
+	vaddr, off := e.addSegment(interp, elf.PF_R)
+	progs = e.readProgs()
+	progs[index].Off = off
+	progs[index].Vaddr = vaddr
+	progs[index].Paddr = vaddr
+	progs[index].Filesz = uint64(len(interp))
+	progs[index].Memsz = uint64(len(interp))
+	e.writeProgs(progs)
+	e.updateSection(".interp", vaddr, off, uint64(len(interp)))
+}
+
+func (e *ELFer) SetRunpath(paths ...string) {
+	e.dirty = true
+	runpath := strings.Join(paths, ":")
+
+	dynOff, dyns := e.readDynamic()
+	var strtab, strsz uint64
+	entry := -1
+	for i, dyn := range dyns {
+		switch elf.DynTag(dyn.Tag) {
+		case elf.DT_STRTAB:
+			strtab = dyn.Val
+		case elf.DT_STRSZ:
+			strsz = dyn.Val
+		case elf.DT_RUNPATH, elf.DT_RPATH:
+			entry = i
+		}
+	}
+	if strtab == 0 {
+		utils.Fatal("DT_STRTAB not found")
+	}
+	strOff := e.VaddrToOffset(e.base + strtab)
+
+	if entry >= 0 {
+		old := e.CString(e.base + strtab + dyns[entry].Val)
+		if len(runpath) <= len(old) {
+			pos := strOff + dyns[entry].Val
+			copy(e.raw[pos:pos+uint64(len(old))], make([]byte, len(old)))
+			copy(e.raw[pos:], runpath)
+			dyns[entry].Tag = int64(elf.DT_RUNPATH)
+			e.writeDynamic(dynOff, dyns)
+			return
+		}
+	}
+
+	used := 0
+	for used < len(dyns) && elf.DynTag(dyns[used].Tag) != elf.DT_NULL {
+		used++
+	}
+	moveDynamic := entry < 0 && len(dyns)-used < 2
+
+	dynstr := append(append([]byte{}, e.raw[strOff:strOff+strsz]...), runpath...)
+	dynstr = append(dynstr, 0)
+	runpathVal := strsz
+
+	if entry < 0 {
+		dyns = append(dyns[:used:used], elf.Dyn64{Tag: int64(elf.DT_RUNPATH)}, elf.Dyn64{Tag: int64(elf.DT_NULL)})
+		entry = used
+	}
+	dyns[entry].Tag = int64(elf.DT_RUNPATH)
+	dyns[entry].Val = runpathVal
+
+	blob := dynstr
+	dynStart := alignUp(len(blob), 16)
+	if moveDynamic {
+		blob = append(blob, make([]byte, dynStart-len(blob))...)
+		blob = append(blob, e.encodeDynamic(dyns)...)
+	}
+
+	vaddr, off := e.addSegment(blob, elf.PF_R|elf.PF_W)
+	for i, dyn := range dyns {
+		switch elf.DynTag(dyn.Tag) {
+		case elf.DT_STRTAB:
+			dyns[i].Val = vaddr
+		case elf.DT_STRSZ:
+			dyns[i].Val = uint64(len(dynstr))
+		}
+	}
+	e.updateSection(".dynstr", vaddr, off, uint64(len(dynstr)))
+
+	if !moveDynamic {
+		e.writeDynamic(dynOff, dyns)
+		return
+	}
+
+	dynVaddr := vaddr + uint64(dynStart)
+	dynFileOff := off + uint64(dynStart)
+	dynSize := uint64(len(e.encodeDynamic(dyns)))
+	e.writeDynamic(dynFileOff, dyns)
+
+	progs := e.readProgs()
+	for i := range progs {
+		if elf.ProgType(progs[i].Type) == elf.PT_DYNAMIC {
+			progs[i].Off = dynFileOff
+			progs[i].Vaddr = dynVaddr
+			progs[i].Paddr = dynVaddr
+			progs[i].Filesz = dynSize
+			progs[i].Memsz = dynSize
+		}
+	}
+	e.writeProgs(progs)
+	e.updateSection(".dynamic", dynVaddr, dynFileOff, dynSize)
+}
+
+func (e *ELFer) addSegment(data []byte, flags elf.ProgFlag) (uint64, uint64) {
+	progs := e.readProgs()
+
+	pageSize := uint64(0x1000)
+	var delta, maxEnd uint64
+	foundLoad := false
+	for _, prog := range progs {
+		if elf.ProgType(prog.Type) != elf.PT_LOAD {
+			continue
+		}
+		if !foundLoad {
+			delta = prog.Vaddr - prog.Off
+			foundLoad = true
+		}
+		if prog.Align > pageSize {
+			pageSize = prog.Align
+		}
+		if end := prog.Vaddr + prog.Memsz; end > maxEnd {
+			maxEnd = end
+		}
+	}
+	if !foundLoad {
+		utils.Fatal("no PT_LOAD segment found")
+	}
+
+	offset := uint64(len(e.raw))
+	if maxEnd-delta > offset {
+		offset = maxEnd - delta
+	}
+	offset = uint64(alignUp(int(offset), int(pageSize)))
+	vaddr := offset + delta
+
+	phdrSize := uint64(len(e.encodeProgs(progs))) / uint64(len(progs)) * uint64(len(progs)+1)
+	dataOff := uint64(alignUp(int(phdrSize), 16))
+	size := dataOff + uint64(len(data))
+
+	for i := range progs {
+		if elf.ProgType(progs[i].Type) == elf.PT_PHDR {
+			progs[i].Off = offset
+			progs[i].Vaddr = vaddr
+			progs[i].Paddr = vaddr
+			progs[i].Filesz = phdrSize
+			progs[i].Memsz = phdrSize
+		}
+	}
+	progs = append(progs, elf.Prog64{
+		Type:   uint32(elf.PT_LOAD),
+		Flags:  uint32(flags),
+		Off:    offset,
+		Vaddr:  vaddr,
+		Paddr:  vaddr,
+		Filesz: size,
+		Memsz:  size,
+		Align:  pageSize,
+	})
+
+	raw := make([]byte, offset+size)
+	copy(raw, e.raw)
+	copy(raw[offset:], e.encodeProgs(progs))
+	copy(raw[offset+dataOff:], data)
+
+	order := e.file.ByteOrder
+	if e.file.Class == elf.ELFCLASS64 {
+		order.PutUint64(raw[0x20:], offset)
+		order.PutUint16(raw[0x38:], uint16(len(progs)))
+	} else {
+		order.PutUint32(raw[0x1c:], uint32(offset))
+		order.PutUint16(raw[0x2c:], uint16(len(progs)))
+	}
+
+	e.reload(raw)
+	return vaddr + dataOff, offset + dataOff
+}
+
+func (e *ELFer) reload(raw []byte) {
+	file, err := elf.NewFile(bytes.NewReader(raw))
+	if err != nil {
+		utils.Fatal("failed to reparse ELF: %v", err)
+	}
+	e.raw = raw
+	e.file = file
+}
+
+func (e *ELFer) readProgs() []elf.Prog64 {
+	order := e.file.ByteOrder
+	var phoff uint64
+	var phnum uint16
+	if e.file.Class == elf.ELFCLASS64 {
+		phoff = order.Uint64(e.raw[0x20:])
+		phnum = order.Uint16(e.raw[0x38:])
+	} else {
+		phoff = uint64(order.Uint32(e.raw[0x1c:]))
+		phnum = order.Uint16(e.raw[0x2c:])
+	}
+
+	r := bytes.NewReader(e.raw[phoff:])
+	progs := make([]elf.Prog64, phnum)
+	for i := range progs {
+		if e.file.Class == elf.ELFCLASS64 {
+			binary.Read(r, order, &progs[i])
+			continue
+		}
+		var p elf.Prog32
+		binary.Read(r, order, &p)
+		progs[i] = elf.Prog64{
+			Type:   p.Type,
+			Flags:  p.Flags,
+			Off:    uint64(p.Off),
+			Vaddr:  uint64(p.Vaddr),
+			Paddr:  uint64(p.Paddr),
+			Filesz: uint64(p.Filesz),
+			Memsz:  uint64(p.Memsz),
+			Align:  uint64(p.Align),
+		}
+	}
+	return progs
+}
+
+func (e *ELFer) encodeProgs(progs []elf.Prog64) []byte {
+	var buf bytes.Buffer
+	for _, p := range progs {
+		if e.file.Class == elf.ELFCLASS64 {
+			binary.Write(&buf, e.file.ByteOrder, p)
+			continue
+		}
+		binary.Write(&buf, e.file.ByteOrder, elf.Prog32{
+			Type:   p.Type,
+			Off:    uint32(p.Off),
+			Vaddr:  uint32(p.Vaddr),
+			Paddr:  uint32(p.Paddr),
+			Filesz: uint32(p.Filesz),
+			Memsz:  uint32(p.Memsz),
+			Flags:  p.Flags,
+			Align:  uint32(p.Align),
+		})
+	}
+	return buf.Bytes()
+}
+
+func (e *ELFer) writeProgs(progs []elf.Prog64) {
+	var phoff uint64
+	if e.file.Class == elf.ELFCLASS64 {
+		phoff = e.file.ByteOrder.Uint64(e.raw[0x20:])
+	} else {
+		phoff = uint64(e.file.ByteOrder.Uint32(e.raw[0x1c:]))
+	}
+	copy(e.raw[phoff:], e.encodeProgs(progs))
+	e.reload(e.raw)
+}
+
+func (e *ELFer) readDynamic() (uint64, []elf.Dyn64) {
+	for _, prog := range e.readProgs() {
+		if elf.ProgType(prog.Type) != elf.PT_DYNAMIC {
+			continue
+		}
+
+		r := bytes.NewReader(e.raw[prog.Off : prog.Off+prog.Filesz])
+		var dyns []elf.Dyn64
+		for r.Len() > 0 {
+			var dyn elf.Dyn64
+			if e.file.Class == elf.ELFCLASS64 {
+				if binary.Read(r, e.file.ByteOrder, &dyn) != nil {
+					break
+				}
+			} else {
+				var d elf.Dyn32
+				if binary.Read(r, e.file.ByteOrder, &d) != nil {
+					break
+				}
+				dyn = elf.Dyn64{Tag: int64(d.Tag), Val: uint64(d.Val)}
+			}
+			dyns = append(dyns, dyn)
+		}
+		return prog.Off, dyns
+	}
+	utils.Fatal("PT_DYNAMIC not found")
+	return 0, nil
+}
+
+func (e *ELFer) encodeDynamic(dyns []elf.Dyn64) []byte {
+	var buf bytes.Buffer
+	for _, d := range dyns {
+		if e.file.Class == elf.ELFCLASS64 {
+			binary.Write(&buf, e.file.ByteOrder, d)
+		} else {
+			binary.Write(&buf, e.file.ByteOrder, elf.Dyn32{Tag: int32(d.Tag), Val: uint32(d.Val)})
+		}
+	}
+	return buf.Bytes()
+}
+
+func (e *ELFer) writeDynamic(off uint64, dyns []elf.Dyn64) {
+	copy(e.raw[off:], e.encodeDynamic(dyns))
+	e.reload(e.raw)
+}
+
+func (e *ELFer) updateSection(name string, addr uint64, off uint64, size uint64) {
+	order := e.file.ByteOrder
+	for i, section := range e.file.Sections {
+		if section.Name != name {
+			continue
+		}
+
+		if e.file.Class == elf.ELFCLASS64 {
+			shoff := order.Uint64(e.raw[0x28:])
+			shentsize := uint64(order.Uint16(e.raw[0x3a:]))
+			pos := shoff + uint64(i)*shentsize
+			order.PutUint64(e.raw[pos+0x10:], addr)
+			order.PutUint64(e.raw[pos+0x18:], off)
+			order.PutUint64(e.raw[pos+0x20:], size)
+		} else {
+			shoff := uint64(order.Uint32(e.raw[0x20:]))
+			shentsize := uint64(order.Uint16(e.raw[0x2e:]))
+			pos := shoff + uint64(i)*shentsize
+			order.PutUint32(e.raw[pos+0x0c:], uint32(addr))
+			order.PutUint32(e.raw[pos+0x10:], uint32(off))
+			order.PutUint32(e.raw[pos+0x14:], uint32(size))
+		}
+		e.reload(e.raw)
+		return
+	}
+}
@@ -0,0 +1,222 @@
+package elf
+
+import (
+	"debug/elf"
+	"encoding/binary"
+)
+
+var pltSections = []string{".plt.sec", ".plt", ".plt.got", ".iplt"}
+
+var pltLayouts = map[elf.Machine][2]uint64{
+	elf.EM_386:       {16, 16},
+	elf.EM_X86_64:    {16, 16},
+	elf.EM_AARCH64:   {32, 16},
+	elf.EM_ARM:       {20, 12},
+	elf.EM_RISCV:     {32, 16},
+	elf.EM_S390:      {32, 32},
+	elf.EM_LOONGARCH: {32, 16},
+}
+
+func (e *ELFer) PLTs() map[string]uint64 {
+	plts := make(map[string]uint64, len(e.plt))
+	for name, addr := range e.plt {
+		plts[name] = addr
+	}
+	return plts
+}
+
+func (e *ELFer) resolvePLT() map[string]uint64 {
+	plts := make(map[string]uint64)
+
+	ifuncs := e.ifuncNames()
+	slots := make(map[uint64]string)
+	var jumpSlots []string
+	for _, rel := range e.relocs {
+		name := e.slotName(rel, ifuncs)
+		if name == "" {
+			continue
+		}
+		if _, exists := slots[rel.Offset]; !exists {
+			slots[rel.Offset] = name
+		}
+		if e.relocKind(rel.Type) == RelocJumpSlot {
+			jumpSlots = append(jumpSlots, name)
+		}
+	}
+
+	for _, name := range pltSections {
+		section := e.file.Section(name)
+		if section == nil {
+			continue
+		}
+		data, err := section.Data()
+		if err != nil {
+			continue
+		}
+
+		for _, stub := range e.pltStubs(section, data) {
+			symbol, ok := slots[stub.slot]
+			if !ok {
+				continue
+			}
+			if _, exists := plts[symbol]; !exists {
+				plts[symbol] = stub.addr
+			}
+		}
+	}
+
+	if len(plts) == 0 {
+		if section := e.file.Section(".plt"); section != nil {
+			if layout, ok := pltLayouts[e.file.Machine]; ok {
+				for i, symbol := range jumpSlots {
+					plts[symbol] = section.Addr + layout[0] + uint64(i)*layout[1]
+				}
+			}
+		}
+	}
+
+	return plts
+}
+
+type pltStub struct {
+	addr uint64
+	slot uint64
+}
+
+func (e *ELFer) pltStubs(section *elf.Section, data []byte) []pltStub {
+	switch e.file.Machine {
+	case elf.EM_X86_64, elf.EM_386:
+		return e.x86Stubs(section, data)
+	case elf.EM_AARCH64:
+		return e.arm64Stubs(section.Addr, data)
+	case elf.EM_ARM:
+		return e.armStubs(section.Addr, data)
+	case elf.EM_RISCV:
+		return e.riscvStubs(section.Addr, data)
+	}
+	return nil
+}
+
+func (e *ELFer) x86Stubs(section *elf.Section, data []byte) []pltStub {
+	entSize := section.Entsize
+	if entSize == 0 {
+		entSize = 16
+	}
+
+	var gotBase uint64
+	if e.file.Machine == elf.EM_386 {
+		gotBase = e.gotBase()
+	}
+
+	var stubs []pltStub
+	for off := uint64(0); off+entSize <= uint64(len(data)); off += entSize {
+		entry := data[off : off+entSize]
+		addr := section.Addr + off
+
+		for i := 0; i+6 <= len(entry); i++ {
+			if entry[i] != 0xff {
+				continue
+			}
+			disp := int32(binary.LittleEndian.Uint32(entry[i+2 : i+6]))
+
+			if entry[i+1] == 0x25 && e.file.Machine == elf.EM_X86_64 {
+				stubs = append(stubs, pltStub{addr, addr + uint64(i) + 6 + uint64(int64(disp))})
+				break
+			}
+			if entry[i+1] == 0x25 && e.file.Machine == elf.EM_386 {
+				stubs = append(stubs, pltStub{addr, uint64(uint32(disp))})
+				break
+			}
+			if entry[i+1] == 0xa3 && e.file.Machine == elf.EM_386 {
+				stubs = append(stubs, pltStub{addr, uint64(uint32(gotBase + uint64(int64(disp))))})
+				break
+			}
+		}
+	}
+	return stubs
+}
+
+func (e *ELFer) arm64Stubs(base uint64, data []byte) []pltStub {
+	const bti = 0xd503245f
+
+	var stubs []pltStub
+	for i := 0; i+8 <= len(data); i += 4 {
+		adrp := e.file.ByteOrder.Uint32(data[i:])
+		ldr := e.file.ByteOrder.Uint32(data[i+4:])
+		if adrp&0x9f00001f != 0x90000010 || ldr&0xffc003ff != 0xf9400211 {
+			continue
+		}
+
+		imm := uint64(adrp>>29&3) | uint64(adrp>>5&0x7ffff)<<2
+		page := int64(imm<<43) >> 31
+		pc := base + uint64(i)
+		slot := (pc &^ 0xfff) + uint64(page) + uint64(ldr>>10&0xfff)*8
+
+		start := pc
+		if i >= 4 && e.file.ByteOrder.Uint32(data[i-4:]) == bti {
+			start -= 4
+		}
+		stubs = append(stubs, pltStub{start, slot})
+	}
+	return stubs
+}
+
+func (e *ELFer) armStubs(base uint64, data []byte) []pltStub {
+	var stubs []pltStub
+	for i := 0; i+12 <= len(data); i += 4 {
+		first := e.file.ByteOrder.Uint32(data[i:])
+		second := e.file.ByteOrder.Uint32(data[i+4:])
+		third := e.file.ByteOrder.Uint32(data[i+8:])
+		if first&0xfffff000 != 0xe28fc000 || second&0xfffff000 != 0xe28cc000 || third&0xff7ff000 != 0xe53cf000 {
+			continue
+		}
+
+		pc := base + uint64(i)
+		ip := uint32(pc) + 8 + armImmediate(first) + armImmediate(second)
+		if third&(1<<23) != 0 {
+			ip += third & 0xfff
+		} else {
+			ip -= third & 0xfff
+		}
+		stubs = append(stubs, pltStub{pc, uint64(ip)})
+	}
+	return stubs
+}
+
+func armImmediate(insn uint32) uint32 {
+	value := insn & 0xff
+	rotate := (insn >> 8 & 0xf) * 2
+	return value>>rotate | value<<(32-rotate)
+}
+
+func (e *ELFer) riscvStubs(base uint64, data []byte) []pltStub {
+	var stubs []pltStub
+	for i := 0; i+8 <= len(data); i += 4 {
+		auipc := e.file.ByteOrder.Uint32(data[i:])
+		load := e.file.ByteOrder.Uint32(data[i+4:])
+		if auipc&0xfff != 0xe17 || load&0xf8fff != 0xe0e03 {
+			continue
+		}
+
+		pc := base + uint64(i)
+		hi := int64(int32(auipc & 0xfffff000))
+		lo := int64(int32(load) >> 20)
+		stubs = append(stubs, pltStub{pc, uint64(int64(pc) + hi + lo)})
+	}
+	return stubs
+}
+
+// gotBase is the i386 PIC register value: code addresses GOT slots as
+// [ebx+disp] with ebx pointing at _GLOBAL_OFFSET_TABLE_.
+func (e *ELFer) gotBase() uint64 {
+	if addr, ok := e.rawSym["_GLOBAL_OFFSET_TABLE_"]; ok {
+		return addr
+	}
+	if got := e.file.Section(".got.plt"); got != nil {
+		return got.Addr
+	}
+	if got := e.file.Section(".got"); got != nil {
+		return got.Addr
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package elf
+
+import (
+	"debug/elf"
+	"io"
+	"os"
+	"pwner/utils"
+)
+
+func FromBytes(data []byte) *ELFer {
+	e := load("", append([]byte{}, data...))
+	e.summary()
+	return e
+}
+
+func FromReaderAt(r io.ReaderAt) *ELFer {
+	size := readerSize(r)
+	if size < 0 {
+		file, err := elf.NewFile(r)
+		if err != nil {
+			utils.Fatal("failed to open ELF: %v", err)
+		}
+		size = fileExtent(file, r)
+	}
+
+	raw := make([]byte, size)
+	n, err := r.ReadAt(raw, 0)
+	if err != nil && err != io.EOF {
+		utils.Fatal("failed to read ELF: %v", err)
+	}
+	e := load("", raw[:n])
+	e.summary()
+	return e
+}
+
+func readerSize(r io.ReaderAt) int64 {
+	switch v := r.(type) {
+	case interface{ Size() int64 }:
+		return v.Size()
+	case *os.File:
+		info, err := v.Stat()
+		if err == nil {
+			return info.Size()
+		}
+	}
+	return -1
+}
+
+func fileExtent(file *elf.File, r io.ReaderAt) int64 {
+	var end uint64
+	extend := func(off uint64, size uint64) {
+		if off+size > end {
+			end = off + size
+		}
+	}
+
+	for _, section := range file.Sections {
+		if section.Type != elf.SHT_NOBITS {
+			extend(section.Offset, section.FileSize)
+		}
+	}
+	for _, prog := range file.Progs {
+		extend(prog.Off, prog.Filesz)
+	}
+
+	header := make([]byte, 64)
+	if _, err := r.ReadAt(header, 0); err == nil || err == io.EOF {
+		order := file.ByteOrder
+		if file.Class == elf.ELFCLASS64 {
+			extend(order.Uint64(header[0x28:]), uint64(order.Uint16(header[0x3c:]))*uint64(order.Uint16(header[0x3a:])))
+		} else {
+			extend(uint64(order.Uint32(header[0x20:])), uint64(order.Uint16(header[0x30:]))*uint64(order.Uint16(header[0x2e:])))
+		}
+	}
+
+	return int64(end)
+}
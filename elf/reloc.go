@@ -0,0 +1,319 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const (
+	RelocJumpSlot  = "JUMP_SLOT"
+	RelocGlobDat   = "GLOB_DAT"
+	RelocCopy      = "COPY"
+	RelocIRelative = "IRELATIVE"
+)
+
+const (
+	rRiscvIRelative elf.R_RISCV = 58
+	r390IRelative   elf.R_390   = 61
+)
+
+type GOTEntry struct {
+	Addr  uint64
+	Kind  string
+	Type  uint32
+	Reloc string
+}
+
+type relocation struct {
+	Offset uint64
+	Type   uint32
+	Symbol string
+	Addend int64
+}
+
+func (e *ELFer) dynamicRelocations() []relocation {
+	dynSymbols, _ := e.file.DynamicSymbols()
+
+	var relocs []relocation
+	for _, section := range e.file.Sections {
+		if section.Type != elf.SHT_REL && section.Type != elf.SHT_RELA {
+			continue
+		}
+		link := elf.SHT_NULL
+		if section.Link != 0 && int(section.Link) < len(e.file.Sections) {
+			link = e.file.Sections[section.Link].Type
+		}
+		// Static binaries keep their IRELATIVE relocations in a .rela.plt
+		// linked to .symtab or to nothing.
+		static := section.Flags&elf.SHF_ALLOC != 0 && (link == elf.SHT_NULL || link == elf.SHT_SYMTAB)
+		if link != elf.SHT_DYNSYM && !static {
+			continue
+		}
+
+		data, err := section.Data()
+		if err != nil {
+			continue
+		}
+		if !static {
+			relocs = append(relocs, e.parseRelocations(data, section.Type == elf.SHT_RELA, dynSymbols)...)
+			continue
+		}
+		for _, rel := range e.parseRelocations(data, section.Type == elf.SHT_RELA, nil) {
+			if e.relocKind(rel.Type) == RelocIRelative {
+				relocs = append(relocs, rel)
+			}
+		}
+	}
+	return relocs
+}
+
+func (e *ELFer) parseRelocations(data []byte, rela bool, symbols []elf.Symbol) []relocation {
+	var relocs []relocation
+	r := bytes.NewReader(data)
+	order := e.file.ByteOrder
+
+	for r.Len() > 0 {
+		var rel relocation
+		var symIndex uint32
+
+		switch {
+		case e.file.Class == elf.ELFCLASS64 && rela:
+			var entry elf.Rela64
+			if binary.Read(r, order, &entry) != nil {
+				return relocs
+			}
+			rel = relocation{Offset: entry.Off, Type: elf.R_TYPE64(entry.Info), Addend: entry.Addend}
+			symIndex = elf.R_SYM64(entry.Info)
+		case e.file.Class == elf.ELFCLASS64:
+			var entry elf.Rel64
+			if binary.Read(r, order, &entry) != nil {
+				return relocs
+			}
+			rel = relocation{Offset: entry.Off, Type: elf.R_TYPE64(entry.Info)}
+			symIndex = elf.R_SYM64(entry.Info)
+		case rela:
+			var entry elf.Rela32
+			if binary.Read(r, order, &entry) != nil {
+				return relocs
+			}
+			rel = relocation{Offset: uint64(entry.Off), Type: elf.R_TYPE32(entry.Info), Addend: int64(entry.Addend)}
+			symIndex = elf.R_SYM32(entry.Info)
+		default:
+			var entry elf.Rel32
+			if binary.Read(r, order, &entry) != nil {
+				return relocs
+			}
+			rel = relocation{Offset: uint64(entry.Off), Type: elf.R_TYPE32(entry.Info)}
+			symIndex = elf.R_SYM32(entry.Info)
+		}
+
+		// DynamicSymbols omits the null symbol at index 0.
+		if symIndex > 0 && int(symIndex) <= len(symbols) {
+			rel.Symbol = symbols[symIndex-1].Name
+		}
+		relocs = append(relocs, rel)
+	}
+	return relocs
+}
+
+func (e *ELFer) GOTs() map[string]GOTEntry {
+	gots := make(map[string]GOTEntry, len(e.got))
+	for name, entry := range e.got {
+		gots[name] = entry
+	}
+	return gots
+}
+
+func (e *ELFer) resolveGOT() map[string]GOTEntry {
+	ifuncs := e.ifuncNames()
+
+	gots := make(map[string]GOTEntry)
+	for _, rel := range e.relocs {
+		kind := e.relocKind(rel.Type)
+		if kind == "" {
+			continue
+		}
+
+		name := e.slotName(rel, ifuncs)
+		if name == "" {
+			continue
+		}
+
+		if existing, exists := gots[name]; exists && existing.Kind == RelocJumpSlot {
+			continue
+		}
+		gots[name] = GOTEntry{
+			Addr:  rel.Offset,
+			Kind:  kind,
+			Type:  rel.Type,
+			Reloc: e.relocName(rel.Type),
+		}
+	}
+	return gots
+}
+
+func (e *ELFer) relocKind(t uint32) string {
+	switch e.file.Machine {
+	case elf.EM_X86_64:
+		switch elf.R_X86_64(t) {
+		case elf.R_X86_64_JMP_SLOT:
+			return RelocJumpSlot
+		case elf.R_X86_64_GLOB_DAT:
+			return RelocGlobDat
+		case elf.R_X86_64_COPY:
+			return RelocCopy
+		case elf.R_X86_64_IRELATIVE:
+			return RelocIRelative
+		}
+	case elf.EM_386:
+		switch elf.R_386(t) {
+		case elf.R_386_JMP_SLOT:
+			return RelocJumpSlot
+		case elf.R_386_GLOB_DAT:
+			return RelocGlobDat
+		case elf.R_386_COPY:
+			return RelocCopy
+		case elf.R_386_IRELATIVE:
+			return RelocIRelative
+		}
+	case elf.EM_AARCH64:
+		switch elf.R_AARCH64(t) {
+		case elf.R_AARCH64_JUMP_SLOT:
+			return RelocJumpSlot
+		case elf.R_AARCH64_GLOB_DAT:
+			return RelocGlobDat
+		case elf.R_AARCH64_COPY:
+			return RelocCopy
+		case elf.R_AARCH64_IRELATIVE:
+			return RelocIRelative
+		}
+	case elf.EM_ARM:
+		switch elf.R_ARM(t) {
+		case elf.R_ARM_JUMP_SLOT:
+			return RelocJumpSlot
+		case elf.R_ARM_GLOB_DAT:
+			return RelocGlobDat
+		case elf.R_ARM_COPY:
+			return RelocCopy
+		case elf.R_ARM_IRELATIVE:
+			return RelocIRelative
+		}
+	case elf.EM_RISCV:
+		switch elf.R_RISCV(t) {
+		case elf.R_RISCV_JUMP_SLOT:
+			return RelocJumpSlot
+		case elf.R_RISCV_COPY:
+			return RelocCopy
+		case rRiscvIRelative:
+			return RelocIRelative
+		}
+	case elf.EM_S390:
+		switch elf.R_390(t) {
+		case elf.R_390_JMP_SLOT:
+			return RelocJumpSlot
+		case elf.R_390_GLOB_DAT:
+			return RelocGlobDat
+		case elf.R_390_COPY:
+			return RelocCopy
+		case r390IRelative:
+			return RelocIRelative
+		}
+	case elf.EM_LOONGARCH:
+		switch elf.R_LARCH(t) {
+		case elf.R_LARCH_JUMP_SLOT:
+			return RelocJumpSlot
+		case elf.R_LARCH_COPY:
+			return RelocCopy
+		case elf.R_LARCH_IRELATIVE:
+			return RelocIRelative
+		}
+	}
+	return ""
+}
+
+func (e *ELFer) relocName(t uint32) string {
+	switch e.file.Machine {
+	case elf.EM_X86_64:
+		return elf.R_X86_64(t).String()
+	case elf.EM_386:
+		return elf.R_386(t).String()
+	case elf.EM_AARCH64:
+		return elf.R_AARCH64(t).String()
+	case elf.EM_ARM:
+		return elf.R_ARM(t).String()
+	case elf.EM_RISCV:
+		return elf.R_RISCV(t).String()
+	case elf.EM_S390:
+		return elf.R_390(t).String()
+	case elf.EM_LOONGARCH:
+		return elf.R_LARCH(t).String()
+	}
+	return fmt.Sprintf("R_%d", t)
+}
+
+func (e *ELFer) readWord(vaddr uint64) uint64 {
+	if e.file.Class == elf.ELFCLASS64 {
+		if data, ok := e.read(vaddr, 8); ok {
+			return e.file.ByteOrder.Uint64(data)
+		}
+		return 0
+	}
+	if data, ok := e.read(vaddr, 4); ok {
+		return uint64(e.file.ByteOrder.Uint32(data))
+	}
+	return 0
+}
+
+// slotName names the symbol a relocation fills in; IRELATIVE slots are named
+// after their resolver.
+func (e *ELFer) slotName(rel relocation, ifuncs map[uint64]string) string {
+	if e.relocKind(rel.Type) != RelocIRelative {
+		return rel.Symbol
+	}
+	resolver := uint64(rel.Addend)
+	if resolver == 0 {
+		resolver = e.readWord(rel.Offset)
+	}
+	return ifuncs[resolver]
+}
+
+// ifuncNames names IRELATIVE resolvers. Aliases are common (memcmp/bcmp,
+// __rawmemchr/rawmemchr), so the public name wins: no leading underscore
+// first, then global over weak, then alphabetical order.
+func (e *ELFer) ifuncNames() map[uint64]string {
+	weak := make(map[string]bool)
+	for _, file := range []*elf.File{e.file, e.debug} {
+		if file == nil {
+			continue
+		}
+		symtab, _ := file.Symbols()
+		dynsym, _ := file.DynamicSymbols()
+		for _, s := range append(symtab, dynsym...) {
+			if elf.ST_BIND(s.Info) == elf.STB_WEAK {
+				weak[s.Name] = true
+			}
+		}
+	}
+	rank := func(name string) int {
+		r := 0
+		if strings.HasPrefix(name, "_") {
+			r += 2
+		}
+		if weak[name] {
+			r++
+		}
+		return r
+	}
+
+	names := make(map[uint64]string)
+	for name, addr := range e.rawSym {
+		current, exists := names[addr]
+		if !exists || rank(name) < rank(current) || rank(name) == rank(current) && name < current {
+			names[addr] = name
+		}
+	}
+	return names
+}
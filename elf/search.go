@@ -0,0 +1,67 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"pwner/utils"
+)
+
+type SearchOptions struct {
+	Executable bool
+	Writable   bool
+}
+
+func (e *ELFer) Search(needle interface{}, opts ...SearchOptions) []uint64 {
+	var addrs []uint64
+	e.SearchIter(needle, opts...)(func(addr uint64) bool {
+		addrs = append(addrs, addr)
+		return true
+	})
+	return addrs
+}
+
+func (e *ELFer) SearchIter(needle interface{}, opts ...SearchOptions) func(yield func(uint64) bool) {
+	var pattern []byte
+	switch v := needle.(type) {
+	case []byte:
+		pattern = v
+	case string:
+		pattern = []byte(v)
+	default:
+		utils.Fatal("unsupported needle type: %T", v)
+	}
+	if len(pattern) == 0 {
+		utils.Fatal("empty needle")
+	}
+
+	var options SearchOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	return func(yield func(uint64) bool) {
+		for _, prog := range e.file.Progs {
+			if prog.Type != elf.PT_LOAD || prog.Filesz == 0 {
+				continue
+			}
+			if options.Executable && prog.Flags&elf.PF_X == 0 {
+				continue
+			}
+			if options.Writable && prog.Flags&elf.PF_W == 0 {
+				continue
+			}
+
+			data := e.raw[prog.Off : prog.Off+prog.Filesz]
+			for pos := 0; ; pos++ {
+				i := bytes.Index(data[pos:], pattern)
+				if i < 0 {
+					break
+				}
+				pos += i
+				if !yield(e.base + prog.Vaddr + uint64(pos)) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package elf
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"pwner/utils"
+)
+
+// ShellcodeOptions are valid at their zero value: the code is mapped RWX at
+// DefaultShellcodeOptions.LoadAddr unless told otherwise.
+type ShellcodeOptions struct {
+	LoadAddr uint64
+	ReadOnly bool
+}
+
+var DefaultShellcodeOptions = ShellcodeOptions{
+	LoadAddr: 0x400000,
+}
+
+func FromShellcode(code []byte, arch string, opts ...ShellcodeOptions) *ELFer {
+	options := DefaultShellcodeOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.LoadAddr == 0 {
+		options.LoadAddr = DefaultShellcodeOptions.LoadAddr
+	}
+	if options.LoadAddr&0xfff != 0 {
+		utils.Fatal("load address 0x%x is not page aligned", options.LoadAddr)
+	}
+
+	var machine elf.Machine
+	var class elf.Class
+	var flags uint32
+	data := elf.ELFDATA2LSB
+	switch utils.Arch(arch) {
+	case "amd64":
+		machine, class = elf.EM_X86_64, elf.ELFCLASS64
+	case "i386":
+		machine, class = elf.EM_386, elf.ELFCLASS32
+	case "aarch64":
+		machine, class = elf.EM_AARCH64, elf.ELFCLASS64
+	case "arm":
+		machine, class, flags = elf.EM_ARM, elf.ELFCLASS32, 0x05000000
+	case "mips":
+		machine, class, flags, data = elf.EM_MIPS, elf.ELFCLASS32, 0x70001007, elf.ELFDATA2MSB
+	case "mipsel":
+		machine, class, flags = elf.EM_MIPS, elf.ELFCLASS32, 0x70001007
+	case "riscv64":
+		machine, class, flags = elf.EM_RISCV, elf.ELFCLASS64, 0x5
+	default:
+		utils.Fatal("unsupported arch: %s", arch)
+	}
+
+	progFlags := elf.PF_R | elf.PF_X
+	if !options.ReadOnly {
+		progFlags |= elf.PF_W
+	}
+
+	ident := [elf.EI_NIDENT]byte{0x7f, 'E', 'L', 'F', byte(class), byte(data), byte(elf.EV_CURRENT)}
+	var order binary.ByteOrder = binary.LittleEndian
+	if data == elf.ELFDATA2MSB {
+		order = binary.BigEndian
+	}
+
+	var buf bytes.Buffer
+	if class == elf.ELFCLASS64 {
+		const headerSize = 64 + 56
+		size := uint64(headerSize + len(code))
+		binary.Write(&buf, order, elf.Header64{
+			Ident:     ident,
+			Type:      uint16(elf.ET_EXEC),
+			Machine:   uint16(machine),
+			Version:   uint32(elf.EV_CURRENT),
+			Entry:     options.LoadAddr + headerSize,
+			Phoff:     64,
+			Flags:     flags,
+			Ehsize:    64,
+			Phentsize: 56,
+			Phnum:     1,
+		})
+		binary.Write(&buf, order, elf.Prog64{
+			Type:   uint32(elf.PT_LOAD),
+			Flags:  uint32(progFlags),
+			Vaddr:  options.LoadAddr,
+			Paddr:  options.LoadAddr,
+			Filesz: size,
+			Memsz:  size,
+			Align:  0x1000,
+		})
+	} else {
+		const headerSize = 52 + 32
+		size := uint32(headerSize + len(code))
+		binary.Write(&buf, order, elf.Header32{
+			Ident:     ident,
+			Type:      uint16(elf.ET_EXEC),
+			Machine:   uint16(machine),
+			Version:   uint32(elf.EV_CURRENT),
+			Entry:     uint32(options.LoadAddr) + headerSize,
+			Phoff:     52,
+			Flags:     flags,
+			Ehsize:    52,
+			Phentsize: 32,
+			Phnum:     1,
+		})
+		binary.Write(&buf, order, elf.Prog32{
+			Type:   uint32(elf.PT_LOAD),
+			Flags:  uint32(progFlags),
+			Vaddr:  uint32(options.LoadAddr),
+			Paddr:  uint32(options.LoadAddr),
+			Filesz: size,
+			Memsz:  size,
+			Align:  0x1000,
+		})
+	}
+	buf.Write(code)
+
+	e := load("", buf.Bytes())
+	e.summary()
+	return e
+}
@@ -0,0 +1,78 @@
+package elf
+
+import (
+	"debug/elf"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// X86Mode returns the x86asm decoding mode for the binary, or 0 when it is
+// not an x86 ELF.
+func (e *ELFer) X86Mode() int {
+	switch e.file.Machine {
+	case elf.EM_X86_64:
+		return 64
+	case elf.EM_386:
+		return 32
+	}
+	return 0
+}
+
+// DecodeX86 decodes the instruction at addr. endbr64/endbr32 are returned
+// as a 4-byte NOP since x86asm predates CET.
+func (e *ELFer) DecodeX86(addr uint64) (x86asm.Inst, bool) {
+	mode := e.X86Mode()
+	prog := e.loadSegment(addr - e.base)
+	if mode == 0 || prog == nil {
+		return x86asm.Inst{}, false
+	}
+	n := prog.Vaddr + prog.Filesz - (addr - e.base)
+	if n > 15 {
+		n = 15
+	}
+	code, ok := e.read(addr-e.base, int(n))
+	if !ok {
+		return x86asm.Inst{}, false
+	}
+	if isEndbr(code) {
+		return x86asm.Inst{Op: x86asm.NOP, Len: 4}, true
+	}
+	inst, err := x86asm.Decode(code, mode)
+	return inst, err == nil
+}
+
+// X86Disp sign-extends a displacement; x86asm leaves disp32 zero-extended.
+func X86Disp(m x86asm.Mem) int64 {
+	return int64(int32(m.Disp))
+}
+
+// X86Reg maps a general purpose register to its full-width parent for mode
+// (rax..r15 or eax..edi) and reports whether writing r replaces all of it.
+func X86Reg(r x86asm.Reg, mode int) (x86asm.Reg, bool) {
+	var idx x86asm.Reg
+	full := false
+	switch {
+	case r >= x86asm.AL && r <= x86asm.BL:
+		idx = r - x86asm.AL
+	case r >= x86asm.AH && r <= x86asm.BH:
+		idx = r - x86asm.AH
+	case r >= x86asm.SPB && r <= x86asm.R15B:
+		idx = r - x86asm.SPB + 4
+	case r >= x86asm.AX && r <= x86asm.R15W:
+		idx = r - x86asm.AX
+	case r >= x86asm.EAX && r <= x86asm.R15L:
+		idx, full = r-x86asm.EAX, true
+	case r >= x86asm.RAX && r <= x86asm.R15:
+		idx, full = r-x86asm.RAX, true
+	default:
+		return 0, false
+	}
+	if mode == 64 {
+		return x86asm.RAX + idx, full
+	}
+	return x86asm.EAX + idx, full
+}
+
+func isEndbr(code []byte) bool {
+	return len(code) >= 4 && code[0] == 0xf3 && code[1] == 0x0f && code[2] == 0x1e && (code[3] == 0xfa || code[3] == 0xfb)
+}
@@ -0,0 +1,224 @@
+package elf
+
+import (
+	"debug/elf"
+	"pwner/utils"
+	"sort"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+type Xref struct {
+	Addr     uint64
+	Function string
+}
+
+func (e *ELFer) Xrefs(target uint64) []Xref {
+	return e.xrefs(map[uint64]bool{target: true})
+}
+
+func (e *ELFer) StringXrefs(s string) []Xref {
+	targets := make(map[uint64]bool)
+	for _, addr := range e.Search(s) {
+		targets[addr] = true
+	}
+	if len(targets) == 0 {
+		utils.Fatal("string %q not found", s)
+	}
+	return e.xrefs(targets)
+}
+
+func (e *ELFer) FindMain() uint64 {
+	if addr, ok := e.sym["main"]; ok {
+		return addr
+	}
+
+	if e.X86Mode() == 0 {
+		utils.Fatal("code analysis is not supported for %s", e.file.Machine)
+	}
+	var candidate uint64
+	found := false
+	pc := e.base + e.file.Entry
+	for i := 0; i < 64; i++ {
+		inst, ok := e.DecodeX86(pc)
+		if !ok {
+			break
+		}
+		next := pc + uint64(inst.Len)
+
+		switch inst.Op {
+		case x86asm.LEA, x86asm.MOV:
+			if reg, ok := inst.Args[0].(x86asm.Reg); ok && (reg == x86asm.RDI || reg == x86asm.EDI) {
+				candidate, found = e.operandValue(inst, next)
+			}
+		case x86asm.PUSH:
+			candidate, found = e.operandValue(inst, next)
+		case x86asm.CALL:
+			if e.callsStartMain(inst, next) {
+				if found && e.isCode(candidate-e.base) {
+					return candidate
+				}
+				i = 64
+			}
+		case x86asm.HLT, x86asm.RET, x86asm.JMP:
+			i = 64
+		}
+		pc = next
+	}
+	utils.Fatal("main not found from entry point 0x%x", e.base+e.file.Entry)
+	return 0
+}
+
+func (e *ELFer) callsStartMain(inst x86asm.Inst, next uint64) bool {
+	const name = "__libc_start_main"
+	switch a := inst.Args[0].(type) {
+	case x86asm.Rel:
+		target := next + uint64(a)
+		if addr, ok := e.plt[name]; ok && addr == target {
+			return true
+		}
+		addr, ok := e.sym[name]
+		return ok && addr == target
+	case x86asm.Mem:
+		slot, ok := e.memAddr(a, next)
+		entry, exists := e.got[name]
+		return ok && exists && entry.Addr == slot
+	}
+	return false
+}
+
+// operandValue evaluates the source operand of a lea/mov/push in _start:
+// an immediate, a code address, or a pointer loaded from a GOT slot.
+func (e *ELFer) operandValue(inst x86asm.Inst, next uint64) (uint64, bool) {
+	src := inst.Args[0]
+	if inst.Op != x86asm.PUSH {
+		src = inst.Args[1]
+	}
+	switch a := src.(type) {
+	case x86asm.Imm:
+		return uint64(a) & e.addrMask(), true
+	case x86asm.Mem:
+		target, ok := e.memAddr(a, next)
+		if !ok {
+			return 0, false
+		}
+		if inst.Op == x86asm.LEA {
+			return target, true
+		}
+		return e.pointerAt(target)
+	}
+	return 0, false
+}
+
+// memAddr resolves a memory operand that does not depend on run-time
+// registers: rip-relative, absolute, or i386 PIC code's [ebx+disp] into the GOT.
+func (e *ELFer) memAddr(m x86asm.Mem, next uint64) (uint64, bool) {
+	if m.Index != 0 {
+		return 0, false
+	}
+	switch {
+	case m.Base == x86asm.RIP:
+		return next + uint64(X86Disp(m)), true
+	case m.Base == 0:
+		return uint64(X86Disp(m)) & e.addrMask(), true
+	case m.Base == x86asm.EBX && e.file.Machine == elf.EM_386:
+		if got := e.gotBase(); got != 0 {
+			return (e.base + got + uint64(X86Disp(m))) & e.addrMask(), true
+		}
+	}
+	return 0, false
+}
+
+func (e *ELFer) pointerAt(addr uint64) (uint64, bool) {
+	vaddr := addr - e.base
+	if word := e.readWord(vaddr); word != 0 {
+		return e.base + word, true
+	}
+	for _, rel := range e.relocs {
+		if rel.Offset != vaddr {
+			continue
+		}
+		if rel.Symbol != "" {
+			value, ok := e.rawSym[rel.Symbol]
+			return e.base + value, ok
+		}
+		return e.base + uint64(rel.Addend), rel.Addend != 0
+	}
+	return 0, false
+}
+
+func (e *ELFer) xrefs(targets map[uint64]bool) []Xref {
+	mode := e.x86Mode()
+	funcs := e.Functions()
+
+	var refs []Xref
+	for _, section := range e.file.Sections {
+		if section.Flags&elf.SHF_EXECINSTR == 0 || section.Type == elf.SHT_NOBITS {
+			continue
+		}
+		code, err := section.Data()
+		if err != nil {
+			continue
+		}
+
+		for off := 0; off < len(code); {
+			pc := e.base + section.Addr + uint64(off)
+			if isEndbr(code[off:]) {
+				off += 4
+				continue
+			}
+			inst, err := x86asm.Decode(code[off:], mode)
+			if err != nil {
+				off++
+				continue
+			}
+			off += inst.Len
+
+			for _, target := range e.instTargets(inst, pc+uint64(inst.Len)) {
+				if !targets[target] {
+					continue
+				}
+				ref := Xref{Addr: pc}
+				if f := functionAt(funcs, pc); f != nil {
+					ref.Function = f.Name
+				}
+				refs = append(refs, ref)
+				break
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Addr < refs[j].Addr })
+	return refs
+}
+
+func (e *ELFer) instTargets(inst x86asm.Inst, next uint64) []uint64 {
+	var targets []uint64
+	for _, arg := range inst.Args {
+		switch a := arg.(type) {
+		case x86asm.Rel:
+			targets = append(targets, next+uint64(a))
+		case x86asm.Mem:
+			if addr, ok := e.memAddr(a, next); ok {
+				targets = append(targets, addr)
+			}
+		case x86asm.Imm:
+			targets = append(targets, uint64(a)&e.addrMask())
+		}
+	}
+	return targets
+}
+
+func (e *ELFer) x86Mode() int {
+	mode := e.X86Mode()
+	if mode == 0 {
+		utils.Fatal("code analysis is not supported for %s", e.file.Machine)
+	}
+	return mode
+}
+
+func (e *ELFer) addrMask() uint64 {
+	if e.file.Class == elf.ELFCLASS32 {
+		return 0xffffffff
+	}
+	return ^uint64(0)
+}
@@ -1 +1,5 @@
 module pwner
+
+go 1.23.0
+
+require golang.org/x/arch v0.20.0
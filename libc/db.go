@@ -0,0 +1,162 @@
+package libc
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"pwner/elf"
+	"pwner/utils"
+	"sort"
+	"strings"
+)
+
+type Entry struct {
+	Path    string            `json:"path"`
+	BuildID string            `json:"build_id"`
+	Banner  string            `json:"banner"`
+	Version string            `json:"version"`
+	Symbols map[string]uint64 `json:"symbols"`
+}
+
+type DB struct {
+	Path    string   `json:"-"`
+	Entries []*Entry `json:"entries"`
+}
+
+func Index(dir string, dbPath string) *DB {
+	db := &DB{Path: dbPath}
+	if _, err := os.Stat(dbPath); err == nil {
+		db = Open(dbPath)
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || !strings.Contains(d.Name(), "libc") {
+			return nil
+		}
+		if isELF(path) {
+			db.Add(path)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.Fatal("failed to walk %s: %v", dir, err)
+	}
+
+	db.Save()
+	return db
+}
+
+func Open(dbPath string) *DB {
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		utils.Fatal("failed to open libc database: %v", err)
+	}
+	db := &DB{Path: dbPath}
+	if err := json.Unmarshal(data, db); err != nil {
+		utils.Fatal("failed to parse libc database: %v", err)
+	}
+	return db
+}
+
+func (db *DB) Add(path string) *Entry {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		utils.Fatal("failed to resolve %s: %v", path, err)
+	}
+
+	e := elf.Load(abs)
+	symbols := e.Symbols()
+	if _, ok := symbols["__libc_start_main"]; !ok {
+		return nil
+	}
+
+	entry := &Entry{
+		Path:    abs,
+		BuildID: e.BuildID(),
+		Symbols: symbols,
+	}
+	if addrs := e.Search("/bin/sh\x00"); len(addrs) > 0 {
+		entry.Symbols["str_bin_sh"] = addrs[0]
+	}
+	if version := e.LibcVersion(); version != nil {
+		entry.Banner = version.Banner
+		entry.Version = version.String()
+	}
+
+	for i, existing := range db.Entries {
+		if existing.Path == abs || (entry.BuildID != "" && existing.BuildID == entry.BuildID) {
+			db.Entries[i] = entry
+			return entry
+		}
+	}
+	db.Entries = append(db.Entries, entry)
+	utils.Debug("indexed %s (%s)", abs, entry.Version)
+	return entry
+}
+
+func (db *DB) Save() {
+	data, err := json.Marshal(db)
+	if err != nil {
+		utils.Fatal("failed to encode libc database: %v", err)
+	}
+	if err := os.WriteFile(db.Path, data, 0644); err != nil {
+		utils.Fatal("failed to write libc database: %v", err)
+	}
+}
+
+func (db *DB) Find(leaks map[string]uint64) []*Entry {
+	if len(leaks) == 0 {
+		utils.Fatal("no leaked symbols given")
+	}
+
+	names := make([]string, 0, len(leaks))
+	for name := range leaks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var matches []*Entry
+	for _, entry := range db.Entries {
+		if entry.matches(names, leaks) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+func (entry *Entry) matches(names []string, leaks map[string]uint64) bool {
+	first, ok := entry.Symbols[names[0]]
+	if !ok {
+		return false
+	}
+
+	for _, name := range names {
+		offset, ok := entry.Symbols[name]
+		if !ok || offset&0xfff != leaks[name]&0xfff {
+			return false
+		}
+		if leaks[name]-leaks[names[0]] != offset-first {
+			return false
+		}
+	}
+	return true
+}
+
+func (entry *Entry) ELF() *elf.ELFer {
+	return elf.ELF(entry.Path)
+}
+
+func isELF(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := f.Read(magic); err != nil {
+		return false
+	}
+	return string(magic) == "\x7fELF"
+}
@@ -0,0 +1,475 @@
+package libc
+
+import (
+	debugelf "debug/elf"
+	"fmt"
+	"pwner/elf"
+	"pwner/utils"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+type OneGadget struct {
+	Addr        uint64
+	Constraints []string
+}
+
+func (g OneGadget) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "0x%x execve(\"/bin/sh\", ...)\n", g.Addr)
+	if len(g.Constraints) == 0 {
+		b.WriteString("  constraints: none\n")
+		return b.String()
+	}
+	b.WriteString("  constraints:\n")
+	for _, c := range g.Constraints {
+		fmt.Fprintf(&b, "    %s\n", c)
+	}
+	return b.String()
+}
+
+const gadgetDepth = 48
+
+func OneGadgets(e *elf.ELFer) []OneGadget {
+	mode := e.X86Mode()
+	if mode == 0 {
+		utils.Fatal("one_gadget is only supported for amd64 and i386")
+	}
+
+	binsh := e.Search("/bin/sh\x00")
+	if len(binsh) == 0 {
+		utils.Fatal("\"/bin/sh\" not found")
+	}
+	symbols := e.Symbols()
+	execve, ok := symbols["execve"]
+	if !ok {
+		utils.Fatal("symbol 'execve' not found")
+	}
+
+	s := &scanner{
+		e:      e,
+		mode:   mode,
+		binsh:  binsh[0],
+		execve: execve,
+		names:  make(map[uint64]string),
+	}
+	for name, entry := range e.GOTs() {
+		s.names[entry.Addr] = "&" + name
+	}
+	for _, name := range []string{"environ", "__environ", "_environ"} {
+		if addr, ok := symbols[name]; ok {
+			s.names[addr] = "&environ"
+		}
+	}
+	if mode == 32 {
+		s.got = e.Section(".got.plt").Addr
+	}
+
+	var gadgets []OneGadget
+	for _, seg := range e.Segments() {
+		if seg.Type != debugelf.PT_LOAD || seg.Flags&debugelf.PF_X == 0 || seg.Filesz == 0 {
+			continue
+		}
+		code := e.Read(seg.Addr, int(seg.Filesz))
+		for i := 0; i+7 <= len(code); i++ {
+			start, reg, ok := s.candidate(code[i:], seg.Addr+uint64(i))
+			if !ok {
+				continue
+			}
+			if g, ok := s.trace(start, reg); ok {
+				gadgets = append(gadgets, g)
+			}
+		}
+	}
+
+	sort.Slice(gadgets, func(i, j int) bool { return gadgets[i].Addr < gadgets[j].Addr })
+	return gadgets
+}
+
+type scanner struct {
+	e      *elf.ELFer
+	mode   int
+	binsh  uint64
+	execve uint64
+	got    uint64
+	names  map[uint64]string
+}
+
+// candidate matches `lea r, [rip+disp32]` on amd64 and `lea r, [reg+disp32]`
+// on i386 where the effective address is "/bin/sh".
+func (s *scanner) candidate(code []byte, addr uint64) (uint64, x86asm.Reg, bool) {
+	if s.mode == 64 {
+		if code[0]&0xf8 != 0x48 || code[1] != 0x8d || code[2]&0xc7 != 0x05 {
+			return 0, 0, false
+		}
+		disp := int32(uint32(code[3]) | uint32(code[4])<<8 | uint32(code[5])<<16 | uint32(code[6])<<24)
+		if addr+7+uint64(int64(disp)) != s.binsh {
+			return 0, 0, false
+		}
+		return addr, 0, true
+	}
+
+	if code[0] != 0x8d || code[1]>>6 != 2 || code[1]&7 == 4 {
+		return 0, 0, false
+	}
+	disp := int32(uint32(code[2]) | uint32(code[3])<<8 | uint32(code[4])<<16 | uint32(code[5])<<24)
+	if s.got+uint64(int64(disp)) != s.binsh&0xffffffff {
+		return 0, 0, false
+	}
+	return addr, x86asm.EAX + x86asm.Reg(code[1]&7), true
+}
+
+type value struct {
+	expr  string
+	konst bool
+	n     uint64
+}
+
+var unknown = value{expr: "?"}
+
+func constant(n uint64) value {
+	return value{expr: fmt.Sprintf("0x%x", n), konst: true, n: n}
+}
+
+func symbolic(expr string) value {
+	return value{expr: expr}
+}
+
+type tracker struct {
+	s           *scanner
+	regs        map[x86asm.Reg]value
+	mem         map[string]value
+	sp          int64
+	constraints []string
+}
+
+func (s *scanner) trace(start uint64, gotReg x86asm.Reg) (OneGadget, bool) {
+	t := &tracker{s: s, regs: make(map[x86asm.Reg]value), mem: make(map[string]value)}
+	if gotReg != 0 {
+		t.regs[gotReg] = constant(s.got)
+		t.constraints = append(t.constraints, fmt.Sprintf("%s is the GOT address of libc", t.name(gotReg)))
+	}
+
+	pc := start
+	for n := 0; n < gadgetDepth; n++ {
+		inst, ok := s.e.DecodeX86(pc)
+		if !ok {
+			return OneGadget{}, false
+		}
+		next := pc + uint64(inst.Len)
+
+		switch inst.Op {
+		case x86asm.CALL:
+			rel, ok := inst.Args[0].(x86asm.Rel)
+			if !ok || next+uint64(int64(rel)) != s.execve {
+				return OneGadget{}, false
+			}
+			return t.finish(start)
+		case x86asm.JMP:
+			rel, ok := inst.Args[0].(x86asm.Rel)
+			if !ok {
+				return OneGadget{}, false
+			}
+			pc = next + uint64(int64(rel))
+			continue
+		}
+		if terminates(inst.Op) || !t.step(inst, next) {
+			return OneGadget{}, false
+		}
+		pc = next
+	}
+	return OneGadget{}, false
+}
+
+func terminates(op x86asm.Op) bool {
+	name := op.String()
+	if strings.HasPrefix(name, "J") || strings.HasPrefix(name, "LOOP") {
+		return true
+	}
+	switch op {
+	case x86asm.RET, x86asm.LRET, x86asm.IRET, x86asm.HLT, x86asm.UD2,
+		x86asm.SYSCALL, x86asm.SYSENTER, x86asm.INT:
+		return true
+	}
+	return false
+}
+
+func (t *tracker) step(inst x86asm.Inst, next uint64) bool {
+	args := inst.Args
+	switch inst.Op {
+	case x86asm.NOP, x86asm.CMP, x86asm.TEST:
+		return true
+	case x86asm.LEA:
+		return t.assign(args[0], t.address(args[1].(x86asm.Mem), next), next)
+	case x86asm.MOV:
+		return t.assign(args[0], t.value(args[1], next), next)
+	case x86asm.XOR:
+		if args[0] == args[1] {
+			return t.assign(args[0], constant(0), next)
+		}
+	case x86asm.PUSH:
+		t.sp -= int64(t.width())
+		t.mem[t.stack(t.sp)] = t.value(args[0], next)
+		return true
+	case x86asm.POP:
+		v, ok := t.mem[t.stack(t.sp)]
+		if !ok {
+			v = symbolic("[" + t.stack(t.sp) + "]")
+		}
+		t.sp += int64(t.width())
+		return t.assign(args[0], v, next)
+	case x86asm.ADD, x86asm.SUB:
+		if reg, ok := args[0].(x86asm.Reg); ok && t.canon(reg) == t.spReg() {
+			imm, ok := args[1].(x86asm.Imm)
+			if !ok {
+				return false
+			}
+			if inst.Op == x86asm.SUB {
+				imm = -imm
+			}
+			t.sp += int64(imm)
+			return true
+		}
+	}
+	if args[0] == nil {
+		return true
+	}
+	return t.assign(args[0], unknown, next)
+}
+
+func (t *tracker) assign(dst x86asm.Arg, v value, next uint64) bool {
+	switch d := dst.(type) {
+	case x86asm.Reg:
+		reg := t.canon(d)
+		if reg == 0 || reg == t.spReg() {
+			return false
+		}
+		if !t.full(d) {
+			v = unknown
+		}
+		t.regs[reg] = v
+	case x86asm.Mem:
+		addr := t.address(d, next)
+		if addr.expr == "?" {
+			return false
+		}
+		t.mem[addr.expr] = v
+		if !addr.konst {
+			t.require(addr.expr + " is writable")
+		}
+	}
+	return true
+}
+
+func (t *tracker) value(arg x86asm.Arg, next uint64) value {
+	switch a := arg.(type) {
+	case x86asm.Reg:
+		if !t.full(a) {
+			return unknown
+		}
+		return t.reg(t.canon(a))
+	case x86asm.Imm:
+		return constant(uint64(a))
+	case x86asm.Mem:
+		return t.load(t.address(a, next))
+	}
+	return unknown
+}
+
+func (t *tracker) load(addr value) value {
+	if addr.expr == "?" {
+		return unknown
+	}
+	if v, ok := t.mem[addr.expr]; ok {
+		return v
+	}
+	if name, ok := strings.CutPrefix(addr.expr, "&"); ok {
+		return symbolic(name)
+	}
+	return symbolic("[" + addr.expr + "]")
+}
+
+func (t *tracker) address(m x86asm.Mem, next uint64) value {
+	if m.Index != 0 || (m.Segment != 0 && m.Segment != x86asm.DS) {
+		return unknown
+	}
+
+	disp := elf.X86Disp(m)
+
+	var addr value
+	switch {
+	case m.Base == x86asm.RIP:
+		addr = constant(next + uint64(disp))
+	case m.Base == 0:
+		addr = constant(uint64(disp))
+	case t.canon(m.Base) == t.spReg():
+		return symbolic(t.stack(t.sp + disp))
+	default:
+		base := t.reg(t.canon(m.Base))
+		if base.expr == "?" {
+			return unknown
+		}
+		if !base.konst {
+			expr, off := split(base.expr)
+			return symbolic(offset(expr, off+disp))
+		}
+		addr = constant(base.n + uint64(disp))
+	}
+
+	if t.s.mode == 32 {
+		addr = constant(addr.n & 0xffffffff)
+	}
+	if name, ok := t.s.names[addr.n]; ok {
+		return symbolic(name)
+	}
+	return addr
+}
+
+func (t *tracker) finish(start uint64) (OneGadget, bool) {
+	var args [3]value
+	if t.s.mode == 64 {
+		args = [3]value{t.reg(x86asm.RDI), t.reg(x86asm.RSI), t.reg(x86asm.RDX)}
+	} else {
+		for i := range args {
+			args[i] = t.load(symbolic(t.stack(t.sp + int64(i*4))))
+		}
+	}
+
+	if !args[0].konst || args[0].n != t.s.binsh&t.mask() {
+		return OneGadget{}, false
+	}
+	if !t.nullArray(args[1], "argv") || !t.nullArray(args[2], "envp") {
+		return OneGadget{}, false
+	}
+	return OneGadget{Addr: start, Constraints: t.constraints}, true
+}
+
+// nullArray records what must hold for v to be a valid argv/envp: either
+// NULL itself or a pointer to a NULL-terminated array.
+func (t *tracker) nullArray(v value, kind string) bool {
+	switch {
+	case v.expr == "?":
+		return false
+	case v.konst:
+		return v.n == 0
+	case v.expr == "environ":
+		return true
+	}
+
+	base, disp := split(v.expr)
+	var elems []string
+	var first string
+	for i := 0; i < 8; i++ {
+		slot := offset(base, disp+int64(i*t.width()))
+		entry, ok := t.mem[slot]
+		switch {
+		case !ok && first != "":
+			t.require(fmt.Sprintf("%s == NULL || [%s] == NULL", first, slot))
+			return true
+		case !ok && i == 0 && base != t.name(t.spReg()):
+			t.require(fmt.Sprintf("%s == NULL || [%s] == NULL", v.expr, v.expr))
+			return true
+		case !ok:
+			t.require(fmt.Sprintf("[%s] == NULL", slot))
+			return true
+		case entry.expr == "?":
+			return false
+		case entry.konst && entry.n == 0:
+			if first != "" {
+				elems = append(elems, "NULL")
+				t.require(fmt.Sprintf("%s == NULL || {%s} is a valid %s", first, strings.Join(elems, ", "), kind))
+			}
+			return true
+		case entry.konst && entry.n == t.s.binsh&t.mask():
+			elems = append(elems, "\"/bin/sh\"")
+		default:
+			if first == "" && !entry.konst {
+				first = entry.expr
+			}
+			elems = append(elems, entry.expr)
+		}
+	}
+	return false
+}
+
+// split separates a trailing constant displacement from a symbolic address.
+func split(expr string) (string, int64) {
+	i := strings.LastIndexAny(expr, "+-")
+	if i <= 0 || !strings.HasPrefix(expr[i+1:], "0x") {
+		return expr, 0
+	}
+	n, err := strconv.ParseInt(expr[i+3:], 16, 64)
+	if err != nil {
+		return expr, 0
+	}
+	if expr[i] == '-' {
+		n = -n
+	}
+	return expr[:i], n
+}
+
+func (t *tracker) require(c string) {
+	for _, existing := range t.constraints {
+		if existing == c {
+			return
+		}
+	}
+	t.constraints = append(t.constraints, c)
+}
+
+func (t *tracker) reg(r x86asm.Reg) value {
+	if v, ok := t.regs[r]; ok {
+		return v
+	}
+	return symbolic(t.name(r))
+}
+
+func (t *tracker) stack(off int64) string {
+	return offset(t.name(t.spReg()), off)
+}
+
+func offset(base string, off int64) string {
+	switch {
+	case off > 0:
+		return fmt.Sprintf("%s+0x%x", base, off)
+	case off < 0:
+		return fmt.Sprintf("%s-0x%x", base, -off)
+	}
+	return base
+}
+
+func (t *tracker) canon(r x86asm.Reg) x86asm.Reg {
+	reg, _ := elf.X86Reg(r, t.s.mode)
+	return reg
+}
+
+// full reports whether a write to r replaces the whole register.
+func (t *tracker) full(r x86asm.Reg) bool {
+	_, full := elf.X86Reg(r, t.s.mode)
+	return full
+}
+
+func (t *tracker) spReg() x86asm.Reg {
+	if t.s.mode == 64 {
+		return x86asm.RSP
+	}
+	return x86asm.ESP
+}
+
+func (t *tracker) width() int {
+	return t.s.mode / 8
+}
+
+func (t *tracker) mask() uint64 {
+	if t.s.mode == 64 {
+		return ^uint64(0)
+	}
+	return 0xffffffff
+}
+
+func (t *tracker) name(r x86asm.Reg) string {
+	return strings.ToLower(r.String())
+}
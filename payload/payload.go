@@ -14,19 +14,19 @@ func Pay(args ...interface{}) []byte {
 		case string:
 			payload = append(payload, []byte(v)...)
 		case uint64:
-			payload = append(payload, utils.P64(v)...)
+			payload = append(payload, utils.Pack(v)...)
 		case uint32:
-			payload = append(payload, utils.P64(uint64(v))...)
+			payload = append(payload, utils.Pack(uint64(v))...)
 		case uint16:
-			payload = append(payload, utils.P64(uint64(v))...)
+			payload = append(payload, utils.Pack(uint64(v))...)
 		case uint8:
-			payload = append(payload, utils.P64(uint64(v))...)
+			payload = append(payload, utils.Pack(uint64(v))...)
 		case int:
-			payload = append(payload, utils.P64(uint64(v))...)
+			payload = append(payload, utils.Pack(uint64(v))...)
 		case int64:
-			payload = append(payload, utils.P64(uint64(v))...)
+			payload = append(payload, utils.Pack(uint64(v))...)
 		case int32:
-			payload = append(payload, utils.P64(uint64(v))...)
+			payload = append(payload, utils.Pack(uint64(v))...)
 		default:
 			utils.Fatal("unsupported type: %T", v)
 		}
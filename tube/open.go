@@ -0,0 +1,145 @@
+package tube
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"pwner/utils"
+	"strconv"
+	"strings"
+)
+
+func Open(spec string, opts ...Options) Tube {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		utils.Fatal("empty tube spec")
+	}
+
+	if strings.Contains(spec, "://") {
+		return openURL(spec, opts...)
+	}
+
+	fields := strings.Fields(spec)
+	switch path.Base(fields[0]) {
+	case "nc", "ncat", "netcat":
+		return openNetcat(fields[1:], opts...)
+	}
+
+	if _, err := os.Stat(fields[0]); err != nil {
+		if host, port, ok := splitHostPort(spec); ok {
+			return Remote(host, port, opts...)
+		}
+	}
+
+	return Process(fields, options(opts...))
+}
+
+func openURL(spec string, opts ...Options) Tube {
+	u, err := url.Parse(spec)
+	if err != nil {
+		utils.Fatal("invalid tube url %q: %v", spec, err)
+	}
+
+	switch u.Scheme {
+	case "tcp", "tls", "ssl", "udp":
+		host := u.Hostname()
+		if u.Port() == "" {
+			utils.Fatal("missing port in %q", spec)
+		}
+		port := utils.Dectou16(u.Port())
+		switch u.Scheme {
+		case "tcp":
+			return Remote(host, int(port), opts...)
+		case "udp":
+			return RemoteUDP(host, int(port), opts...)
+		default:
+			return RemoteTLS(host, int(port), opts...)
+		}
+	case "unix":
+		p := u.Path
+		if u.Host != "" {
+			p = u.Host + p
+		}
+		return Unix(p, opts...)
+	case "ssh":
+		args := []string{"ssh", "-T"}
+		if u.Port() != "" {
+			args = append(args, "-p", u.Port())
+		}
+		target := u.Hostname()
+		if u.User != nil {
+			target = u.User.Username() + "@" + target
+		}
+		args = append(args, target)
+		if u.Path != "" && u.Path != "/" {
+			args = append(args, strings.TrimPrefix(u.Path, "/"))
+		}
+		return Process(args, options(opts...))
+	default:
+		utils.Fatal("unsupported tube scheme: %s", u.Scheme)
+	}
+	return nil
+}
+
+func openNetcat(args []string, opts ...Options) Tube {
+	var positional []string
+	secure, udp, unix := false, false, false
+
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--ssl", "--tls":
+			secure = true
+		case "-u", "--udp":
+			udp = true
+		case "-U", "--unixsock":
+			unix = true
+		case "-w", "-q", "-i", "-p", "-s", "-x", "-X", "--wait", "--source-port", "--source":
+			i++
+		default:
+			if !strings.HasPrefix(arg, "-") {
+				positional = append(positional, arg)
+			}
+		}
+	}
+
+	if unix {
+		if len(positional) != 1 {
+			utils.Fatal("usage: nc -U path")
+		}
+		return Unix(positional[0], opts...)
+	}
+
+	if len(positional) != 2 {
+		utils.Fatal("usage: nc host port")
+	}
+	port := int(utils.Dectou16(positional[1]))
+
+	switch {
+	case secure:
+		return RemoteTLS(positional[0], port, opts...)
+	case udp:
+		return RemoteUDP(positional[0], port, opts...)
+	default:
+		return Remote(positional[0], port, opts...)
+	}
+}
+
+func splitHostPort(spec string) (string, int, bool) {
+	host, portStr, err := net.SplitHostPort(spec)
+	if err != nil || host == "" {
+		return "", 0, false
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", 0, false
+	}
+	return host, int(port), true
+}
+
+func options(opts ...Options) Options {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return defaultOptions()
+}
@@ -22,7 +22,7 @@ type Proc struct {
 
 func Process(params ...interface{}) *Proc {
 	var args []string
-	var options Options = DefaultOptions
+	var options Options = defaultOptions()
 
 	for _, param := range params {
 		switch v := param.(type) {
@@ -38,7 +38,7 @@ func Process(params ...interface{}) *Proc {
 	}
 
 	if len(args) == 0 {
-		utils.Fatal("no command specified")
+		args = configCommand()
 	}
 
 	cmd := exec.Command(args[0], args[1:]...)
@@ -79,10 +79,16 @@ func Process(params ...interface{}) *Proc {
 }
 
 func (p *Proc) Send(data []byte) {
-	if p.closed {
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+
+	if p.isClosed() {
 		utils.Fatal("broken tube")
 		return
 	}
+	if p.isEOF() {
+		utils.Fatal("write side is shut down")
+	}
 	_, err := p.stdin.Write(data)
 	if err != nil {
 		utils.Fatal("failed to send data: %v", err)
@@ -91,12 +97,15 @@ func (p *Proc) Send(data []byte) {
 }
 
 func (p *Proc) SendLine(data []byte) {
-	p.Send(append(data, p.options.NewLine...))
+	p.Send(append(data[:len(data):len(data)], p.options.NewLine...))
 	return
 }
 
 func (p *Proc) Recv(n ...int) []byte {
-	if p.closed {
+	p.recvMu.Lock()
+	defer p.recvMu.Unlock()
+
+	if p.isClosed() {
 		utils.Fatal("broken tube")
 	}
 
@@ -109,7 +118,7 @@ func (p *Proc) Recv(n ...int) []byte {
 				if total > 0 {
 					return buf[:total]
 				}
-				utils.Fatal("recv error: %v", err)
+				return p.recvError(err, nil)
 			}
 			total += read
 		}
@@ -122,13 +131,16 @@ func (p *Proc) Recv(n ...int) []byte {
 		if nRead > 0 {
 			return buf[:nRead]
 		}
-		utils.Fatal("recv error: %v", err)
+		return p.recvError(err, nil)
 	}
 	return buf[:nRead]
 }
 
 func (p *Proc) RecvLine() []byte {
-	if p.closed {
+	p.recvMu.Lock()
+	defer p.recvMu.Unlock()
+
+	if p.isClosed() {
 		utils.Fatal("tube is closed")
 	}
 	var buf bytes.Buffer
@@ -136,7 +148,7 @@ func (p *Proc) RecvLine() []byte {
 	for {
 		_, err := p.stdout.Read(b)
 		if err != nil {
-			utils.Fatal("recv error: %v", err)
+			return p.recvError(err, buf.Bytes())
 		}
 		buf.Write(b)
 		if bytes.HasSuffix(buf.Bytes(), p.options.NewLine) {
@@ -147,7 +159,10 @@ func (p *Proc) RecvLine() []byte {
 }
 
 func (p *Proc) RecvUntil(delim []byte) []byte {
-	if p.closed {
+	p.recvMu.Lock()
+	defer p.recvMu.Unlock()
+
+	if p.isClosed() {
 		utils.Fatal("tube is closed")
 	}
 	var buf bytes.Buffer
@@ -155,7 +170,7 @@ func (p *Proc) RecvUntil(delim []byte) []byte {
 	for {
 		_, err := p.stdout.Read(b)
 		if err != nil {
-			utils.Fatal("recv error: %v", err)
+			return p.recvError(err, buf.Bytes())
 		}
 		buf.Write(b)
 		if bytes.HasSuffix(buf.Bytes(), delim) {
@@ -165,18 +180,21 @@ func (p *Proc) RecvUntil(delim []byte) []byte {
 }
 
 func (p *Proc) RecvAll() []byte {
-	if p.closed {
+	p.recvMu.Lock()
+	defer p.recvMu.Unlock()
+
+	if p.isClosed() {
 		utils.Fatal("tube is closed")
 	}
 	ret, err := io.ReadAll(p.stdout)
 	if err != nil {
-		utils.Fatal("recv error: %v", err)
+		return p.recvError(err, ret)
 	}
 	return ret
 }
 
 func (p *Proc) Interactive() {
-	if p.closed {
+	if p.isClosed() {
 		utils.Fatal("tube is closed")
 	}
 
@@ -188,9 +206,11 @@ func (p *Proc) Interactive() {
 	fmt.Printf("%s[pwner]$%s ", utils.ColorRed, utils.ColorReset)
 	for scanner.Scan() {
 		line := scanner.Text()
+		p.sendMu.Lock()
 		p.stdin.Write([]byte(line + "\n"))
+		p.sendMu.Unlock()
 		time.Sleep(100 * time.Millisecond)
-		if p.closed {
+		if p.isClosed() {
 			break
 		}
 		fmt.Printf("%s[pwner]$%s ", utils.ColorRed, utils.ColorReset)
@@ -198,11 +218,30 @@ func (p *Proc) Interactive() {
 	os.Exit(0)
 }
 
+func (p *Proc) ShutdownWrite() {
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+
+	if p.isClosed() {
+		utils.Fatal("broken tube")
+	}
+	if !p.markEOF() {
+		return
+	}
+
+	if err := p.stdin.Close(); err != nil {
+		utils.Fatal("shutdown error: %v", err)
+	}
+}
+
+func (p *Proc) SendEOF() {
+	p.ShutdownWrite()
+}
+
 func (p *Proc) Close() {
-	if p.closed {
+	if !p.markClosed() {
 		utils.Fatal("broken tube")
 	}
-	p.closed = true
 
 	p.stdin.Close()
 	p.stdout.Close()
@@ -212,8 +251,9 @@ func (p *Proc) Close() {
 		p.cmd.Process.Kill()
 	}
 
+	// The process was just killed, so only a failure to reap it is an error.
 	err := p.cmd.Wait()
-	if err != nil {
+	if _, exited := err.(*exec.ExitError); err != nil && !exited {
 		utils.Fatal("close error: %v", err)
 	}
 }
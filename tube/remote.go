@@ -3,29 +3,73 @@ package tube
 import (
 	"bufio"
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"pwner/utils"
+	"strconv"
 	"time"
 )
 
+// maxDatagram is large enough that a single UDP datagram is never
+// truncated when it is read into the receive buffer.
+const maxDatagram = 0x10000
+
 type Remoter struct {
 	baseTube
-	conn net.Conn
-	host string
-	port int
+	conn    net.Conn
+	rd      *bufio.Reader
+	network string
+	host    string
+	port    int
 }
 
 func Remote(host string, port int, opts ...Options) *Remoter {
-	options := DefaultOptions
-	if len(opts) > 0 {
-		options = opts[0]
+	return dial("tcp", host, port, nil, opts...)
+}
+
+func RemoteTLS(host string, port int, opts ...Options) *Remoter {
+	config := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
 	}
+	return dial("tcp", host, port, config, opts...)
+}
+
+func RemoteUDP(host string, port int, opts ...Options) *Remoter {
+	return dial("udp", host, port, nil, opts...)
+}
+
+func Unix(path string, opts ...Options) *Remoter {
+	return dial("unix", path, 0, nil, opts...)
+}
+
+func dial(network string, host string, port int, config *tls.Config, opts ...Options) *Remoter {
+	options := options(opts...)
 
-	address := fmt.Sprintf("%s:%d", host, port)
-	conn, err := net.DialTimeout("tcp", address, options.Timeout)
+	c := utils.LoadConfig()
+	if host == "" {
+		host = c.Host
+	}
+	if port == 0 && network != "unix" {
+		port = c.Port
+	}
+
+	address := host
+	if network != "unix" {
+		address = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+
+	var conn net.Conn
+	var err error
+	if config != nil {
+		dialer := &net.Dialer{Timeout: options.Timeout}
+		conn, err = tls.DialWithDialer(dialer, network, address, config)
+	} else {
+		conn, err = net.DialTimeout(network, address, options.Timeout)
+	}
 	if err != nil {
 		utils.Fatal("failed to connect to %s: %v", address, err)
 	}
@@ -37,22 +81,30 @@ func Remote(host string, port int, opts ...Options) *Remoter {
 			writer:  conn,
 			closed:  false,
 		},
-		conn: conn,
-		host: host,
-		port: port,
+		conn:    conn,
+		rd:      bufio.NewReaderSize(conn, maxDatagram),
+		network: network,
+		host:    host,
+		port:    port,
 	}
 
 	return r
 }
 
 func (r *Remoter) Send(data []byte) {
-	if r.closed {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+
+	if r.isClosed() {
 		utils.Fatal("broken tube")
 		return
 	}
+	if r.isEOF() {
+		utils.Fatal("write side is shut down")
+	}
 
-	if r.options.Timeout > 0 {
-		r.conn.SetWriteDeadline(time.Now().Add(r.options.Timeout))
+	if timeout := r.timeout(); timeout > 0 {
+		r.conn.SetWriteDeadline(time.Now().Add(timeout))
 	}
 
 	_, err := r.conn.Write(data)
@@ -63,28 +115,31 @@ func (r *Remoter) Send(data []byte) {
 }
 
 func (r *Remoter) SendLine(data []byte) {
-	r.Send(append(data, r.options.NewLine...))
+	r.Send(append(data[:len(data):len(data)], r.options.NewLine...))
 	return
 }
 func (r *Remoter) Recv(n ...int) []byte {
-	if r.closed {
+	r.recvMu.Lock()
+	defer r.recvMu.Unlock()
+
+	if r.isClosed() {
 		utils.Fatal("broken tube")
 	}
 
-	if r.options.Timeout > 0 {
-		r.conn.SetReadDeadline(time.Now().Add(r.options.Timeout))
+	if timeout := r.timeout(); timeout > 0 {
+		r.conn.SetReadDeadline(time.Now().Add(timeout))
 	}
 
 	if len(n) > 0 && n[0] > 0 {
 		buf := make([]byte, n[0])
 		total := 0
 		for total < n[0] {
-			read, err := r.conn.Read(buf[total:])
+			read, err := r.rd.Read(buf[total:])
 			if err != nil {
 				if total > 0 {
 					return buf[:total]
 				}
-				utils.Fatal("recv error: %v", err)
+				return r.recvError(err, nil)
 			}
 			total += read
 		}
@@ -92,33 +147,35 @@ func (r *Remoter) Recv(n ...int) []byte {
 	}
 
 	buf := make([]byte, 0x1000)
-	nRead, err := r.conn.Read(buf)
+	nRead, err := r.rd.Read(buf)
 	if err != nil {
 		if nRead > 0 {
 			return buf[:nRead]
 		}
-		utils.Fatal("recv error: %v", err)
+		return r.recvError(err, nil)
 	}
 	return buf[:nRead]
 }
 
 func (r *Remoter) RecvLine() []byte {
-	if r.closed {
+	r.recvMu.Lock()
+	defer r.recvMu.Unlock()
+
+	if r.isClosed() {
 		utils.Fatal("tube is closed")
 	}
 
-	if r.options.Timeout > 0 {
-		r.conn.SetReadDeadline(time.Now().Add(r.options.Timeout))
+	if timeout := r.timeout(); timeout > 0 {
+		r.conn.SetReadDeadline(time.Now().Add(timeout))
 	}
 
 	var buf bytes.Buffer
-	b := make([]byte, 1)
 	for {
-		_, err := r.conn.Read(b)
+		b, err := r.rd.ReadByte()
 		if err != nil {
-			utils.Fatal("recv error: %v", err)
+			return r.recvError(err, buf.Bytes())
 		}
-		buf.Write(b)
+		buf.WriteByte(b)
 		if bytes.HasSuffix(buf.Bytes(), r.options.NewLine) {
 			result := buf.Bytes()
 			return result[:len(result)-len(r.options.NewLine)]
@@ -127,22 +184,24 @@ func (r *Remoter) RecvLine() []byte {
 }
 
 func (r *Remoter) RecvUntil(delim []byte) []byte {
-	if r.closed {
+	r.recvMu.Lock()
+	defer r.recvMu.Unlock()
+
+	if r.isClosed() {
 		utils.Fatal("tube is closed")
 	}
 
-	if r.options.Timeout > 0 {
-		r.conn.SetReadDeadline(time.Now().Add(r.options.Timeout))
+	if timeout := r.timeout(); timeout > 0 {
+		r.conn.SetReadDeadline(time.Now().Add(timeout))
 	}
 
 	var buf bytes.Buffer
-	b := make([]byte, 1)
 	for {
-		_, err := r.conn.Read(b)
+		b, err := r.rd.ReadByte()
 		if err != nil {
-			utils.Fatal("recv error: %v", err)
+			return r.recvError(err, buf.Bytes())
 		}
-		buf.Write(b)
+		buf.WriteByte(b)
 		if bytes.HasSuffix(buf.Bytes(), delim) {
 			return buf.Bytes()
 		}
@@ -150,35 +209,40 @@ func (r *Remoter) RecvUntil(delim []byte) []byte {
 }
 
 func (r *Remoter) RecvAll() []byte {
-	if r.closed {
+	r.recvMu.Lock()
+	defer r.recvMu.Unlock()
+
+	if r.isClosed() {
 		utils.Fatal("tube is closed")
 	}
 
 	r.conn.SetReadDeadline(time.Time{})
 
-	ret, err := io.ReadAll(r.conn)
+	ret, err := io.ReadAll(r.rd)
 	if err != nil {
-		utils.Fatal("recv error: %v", err)
+		return r.recvError(err, ret)
 	}
 	return ret
 }
 
 func (r *Remoter) Interactive() {
-	if r.closed {
+	if r.isClosed() {
 		utils.Fatal("tube is closed")
 	}
 
 	r.conn.SetReadDeadline(time.Time{})
 
-	go io.Copy(os.Stdout, r.conn)
+	go io.Copy(os.Stdout, r.rd)
 
 	scanner := bufio.NewScanner(os.Stdin)
 	fmt.Printf("%s[pwner]$%s ", utils.ColorRed, utils.ColorReset)
 	for scanner.Scan() {
 		line := scanner.Text()
+		r.sendMu.Lock()
 		r.conn.Write([]byte(line + "\n"))
+		r.sendMu.Unlock()
 		time.Sleep(100 * time.Millisecond)
-		if r.closed {
+		if r.isClosed() {
 			break
 		}
 		fmt.Printf("%s[pwner]$%s ", utils.ColorRed, utils.ColorReset)
@@ -187,16 +251,42 @@ func (r *Remoter) Interactive() {
 }
 
 func (r *Remoter) Close() {
-	if r.closed {
+	if !r.markClosed() {
 		utils.Fatal("broken tube")
 	}
-	r.closed = true
 	err := r.conn.Close()
 	if err != nil {
 		utils.Fatal("close error: %v", err)
 	}
 }
 
+func (r *Remoter) ShutdownWrite() {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+
+	if r.isClosed() {
+		utils.Fatal("broken tube")
+	}
+	conn, ok := r.conn.(interface{ CloseWrite() error })
+	if !ok {
+		utils.Fatal("%s connection does not support half-close", r.network)
+	}
+	if !r.markEOF() {
+		return
+	}
+
+	if err := conn.CloseWrite(); err != nil {
+		utils.Fatal("shutdown error: %v", err)
+	}
+}
+
+func (r *Remoter) SendEOF() {
+	r.ShutdownWrite()
+}
+
 func (r *Remoter) GetAddress() string {
-	return fmt.Sprintf("%s:%d", r.host, r.port)
+	if r.network == "unix" {
+		return r.host
+	}
+	return net.JoinHostPort(r.host, strconv.Itoa(r.port))
 }
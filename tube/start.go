@@ -0,0 +1,100 @@
+package tube
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"pwner/utils"
+	"strconv"
+	"strings"
+)
+
+func Start(binary string, host string, port int, opts ...Options) Tube {
+	a := utils.Args()
+	if a.Host != "" {
+		host = a.Host
+	}
+	if a.Port != 0 {
+		port = a.Port
+	}
+
+	if a.Remote {
+		return Remote(host, port, opts...)
+	}
+
+	var args []string
+	if binary != "" {
+		args = []string{binary}
+	} else {
+		args = configCommand()
+	}
+	if a.GDB {
+		return Debug(args, "", opts...)
+	}
+	return Process(withASLR(args, a.NoASLR), options(opts...))
+}
+
+// Debug launches args under gdbserver, stopped before its first instruction,
+// and opens gdb connected to it in a new tmux pane. The target runs once
+// the debugger continues it.
+func Debug(args []string, script string, opts ...Options) *Proc {
+	if _, err := exec.LookPath("gdbserver"); err != nil {
+		utils.Fatal("gdbserver is required for debugging: %v", err)
+	}
+	server := []string{"gdbserver", "--no-startup-with-shell"}
+	if !utils.Args().NoASLR {
+		server = append(server, "--no-disable-randomization")
+	}
+	server = append(server, "localhost:0")
+	p := Process(append(server, args...), options(opts...))
+	port := gdbserverPort(p)
+
+	f, err := os.CreateTemp("", "pwner-gdb-*")
+	if err != nil {
+		utils.Fatal("failed to create gdb script: %v", err)
+	}
+	// gdb deletes the script as soon as it starts reading it.
+	fmt.Fprintf(f, "shell rm -f '%s'\ntarget remote localhost:%d\n%s\n", f.Name(), port, script)
+	f.Close()
+	gdb := []string{"gdb", "-q", args[0], "-x", f.Name()}
+
+	if os.Getenv("TMUX") == "" {
+		utils.Warn("not inside tmux, connect manually: %s", strings.Join(gdb, " "))
+		return p
+	}
+
+	cmd := exec.Command("tmux", append([]string{"split-window", "-h"}, gdb...)...)
+	if err := cmd.Run(); err != nil {
+		utils.Fatal("failed to launch debugger: %v", err)
+	}
+	return p
+}
+
+// gdbserverPort reads gdbserver's banner from stderr up to the line that
+// announces the port it picked.
+func gdbserverPort(p *Proc) int {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		if _, err := p.stderr.Read(b); err != nil {
+			utils.Fatal("gdbserver failed to start: %s", line)
+		}
+		if b[0] != '\n' {
+			line = append(line, b[0])
+			continue
+		}
+		if port, ok := strings.CutPrefix(string(line), "Listening on port "); ok {
+			if n, err := strconv.Atoi(strings.TrimSpace(port)); err == nil {
+				return n
+			}
+		}
+		line = line[:0]
+	}
+}
+
+func withASLR(args []string, noASLR bool) []string {
+	if !noASLR {
+		return args
+	}
+	return append([]string{"setarch", "-R"}, args...)
+}
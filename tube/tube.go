@@ -2,22 +2,36 @@ package tube
 
 import (
 	"io"
+	"path/filepath"
+	"pwner/utils"
+	"sync"
 	"time"
 )
 
+// Tube is safe for one goroutine sending while another receives. Concurrent
+// senders are serialized, as are concurrent receivers, and Close may be
+// called from any goroutine: a receive blocked at that moment returns
+// whatever it had read so far instead of failing.
 type Tube interface {
-	Send(data []byte) error
-	Recv(n int) ([]byte, error)
-	SendLine(data []byte) error
-	RecvLine() ([]byte, error)
-	RecvUntil(delim []byte) ([]byte, error)
-	RecvAll() ([]byte, error)
-	Interactive() error
-	Close() error
+	Send(data []byte)
+	Recv(n ...int) []byte
+	SendLine(data []byte)
+	RecvLine() []byte
+	RecvUntil(delim []byte) []byte
+	RecvAll() []byte
+	Interactive()
+	Close()
+	ShutdownWrite()
+	SendEOF()
 	SetTimeout(timeout time.Duration)
 	IsOpen() bool
 }
 
+var (
+	_ Tube = (*Proc)(nil)
+	_ Tube = (*Remoter)(nil)
+)
+
 type Options struct {
 	Timeout  time.Duration
 	NewLine  []byte
@@ -30,17 +44,100 @@ var DefaultOptions = Options{
 	LogLevel: 0,
 }
 
+func defaultOptions() Options {
+	options := DefaultOptions
+	c := utils.LoadConfig()
+	if c.NewLine != "" {
+		options.NewLine = []byte(c.NewLine)
+	}
+	if c.Timeout != 0 {
+		options.Timeout = c.Timeout
+	}
+	if c.LogLevel != nil {
+		options.LogLevel = *c.LogLevel
+	}
+	return options
+}
+
+func configCommand() []string {
+	c := utils.LoadConfig()
+	if c.Binary == "" {
+		utils.Fatal("no command specified")
+	}
+	if c.Ld == "" {
+		return []string{c.Binary}
+	}
+	libraryPath := filepath.Dir(c.Binary)
+	if c.Libc != "" {
+		libraryPath = filepath.Dir(c.Libc)
+	}
+	return []string{c.Ld, "--library-path", libraryPath, c.Binary}
+}
+
 type baseTube struct {
 	options Options
 	reader  io.Reader
 	writer  io.Writer
 	closed  bool
+	eof     bool
+	mu      sync.Mutex
+	sendMu  sync.Mutex
+	recvMu  sync.Mutex
 }
 
 func (t *baseTube) SetTimeout(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.options.Timeout = timeout
 }
 
 func (t *baseTube) IsOpen() bool {
-	return !t.closed
+	return !t.isClosed()
+}
+
+func (t *baseTube) timeout() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.options.Timeout
+}
+
+func (t *baseTube) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+func (t *baseTube) isEOF() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.eof
+}
+
+// recvError handles a failed read. A tube closed from another goroutine
+// hands back the partial data; any other error is fatal.
+func (t *baseTube) recvError(err error, data []byte) []byte {
+	if !t.isClosed() {
+		utils.Fatal("recv error: %v", err)
+	}
+	return data
+}
+
+func (t *baseTube) markClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return false
+	}
+	t.closed = true
+	return true
+}
+
+func (t *baseTube) markEOF() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.eof {
+		return false
+	}
+	t.eof = true
+	return true
 }
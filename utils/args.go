@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Arguments struct {
+	Remote   bool
+	Local    bool
+	GDB      bool
+	NoASLR   bool
+	Host     string
+	Port     int
+	LogLevel int
+	Extra    map[string]string
+	Rest     []string
+}
+
+var (
+	args     *Arguments
+	argsOnce sync.Once
+)
+
+func Args() *Arguments {
+	argsOnce.Do(func() {
+		LoadConfig()
+		args = parseArgs(os.Environ(), os.Args[1:])
+		LogLevel = args.LogLevel
+	})
+	return args
+}
+
+func parseArgs(environ []string, argv []string) *Arguments {
+	a := &Arguments{
+		LogLevel: LogLevel,
+		Extra:    make(map[string]string),
+	}
+
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, "PWNER_") {
+			continue
+		}
+		key, value := splitArg(strings.TrimPrefix(kv, "PWNER_"))
+		a.set(key, value)
+	}
+
+	for _, arg := range argv {
+		key, value := splitArg(arg)
+		if !a.set(key, value) {
+			a.Rest = append(a.Rest, arg)
+		}
+	}
+
+	return a
+}
+
+func splitArg(arg string) (string, string) {
+	if i := strings.Index(arg, "="); i >= 0 {
+		return arg[:i], arg[i+1:]
+	}
+	return arg, ""
+}
+
+func (a *Arguments) set(key string, value string) bool {
+	if !isArgName(key) {
+		return false
+	}
+
+	switch key {
+	case "REMOTE":
+		a.Remote = isTrue(value)
+	case "LOCAL":
+		a.Local = isTrue(value)
+	case "GDB":
+		a.GDB = isTrue(value)
+	case "NOASLR":
+		a.NoASLR = isTrue(value)
+	case "HOST":
+		a.Host = value
+	case "PORT":
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			Fatal("invalid PORT: %s", value)
+		}
+		a.Port = int(port)
+	case "LOG_LEVEL":
+		a.LogLevel = ParseLogLevel(value)
+	default:
+		a.Extra[key] = value
+	}
+	return true
+}
+
+func isArgName(key string) bool {
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func isTrue(value string) bool {
+	switch strings.ToLower(value) {
+	case "0", "false", "no", "off":
+		return false
+	}
+	return true
+}
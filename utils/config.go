@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ConfigName = ".pwner.toml"
+
+type Config struct {
+	Path      string
+	Binary    string
+	Libc      string
+	Ld        string
+	DebugRoot string
+	Host      string
+	Port      int
+	NewLine   string
+	Timeout   time.Duration
+	LogLevel  *int
+}
+
+var (
+	config     *Config
+	configOnce sync.Once
+)
+
+func LoadConfig() *Config {
+	configOnce.Do(func() {
+		config = &Config{}
+		path := findConfig()
+		if path == "" {
+			return
+		}
+		config = parseConfig(path)
+		if config.LogLevel != nil {
+			LogLevel = *config.LogLevel
+		}
+	})
+	return config
+}
+
+func findConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ConfigName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func parseConfig(path string) *Config {
+	file, err := os.Open(path)
+	if err != nil {
+		Fatal("failed to open config: %v", err)
+	}
+	defer file.Close()
+
+	c := &Config{Path: path}
+	dir := filepath.Dir(path)
+	section := ""
+	scanner := bufio.NewScanner(file)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			Fatal("%s:%d: expected key = value", path, lineNo)
+		}
+		key := strings.TrimSpace(line[:eq])
+		value := parseValue(path, lineNo, strings.TrimSpace(line[eq+1:]))
+		if section != "" {
+			key = section + "." + key
+		}
+
+		switch key {
+		case "binary", "challenge.binary":
+			c.Binary = resolvePath(dir, value)
+		case "libc", "challenge.libc":
+			c.Libc = resolvePath(dir, value)
+		case "ld", "challenge.ld":
+			c.Ld = resolvePath(dir, value)
+		case "debug_root", "challenge.debug_root":
+			c.DebugRoot = resolvePath(dir, value)
+		case "host", "remote.host":
+			c.Host = value
+		case "port", "remote.port":
+			c.Port = int(Dectou16(value))
+		case "newline", "options.newline":
+			c.NewLine = value
+		case "timeout", "options.timeout":
+			c.Timeout = parseTimeout(path, lineNo, value)
+		case "log_level", "options.log_level":
+			level := ParseLogLevel(value)
+			c.LogLevel = &level
+		default:
+			Fatal("%s:%d: unknown key %q", path, lineNo, key)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		Fatal("failed to read config: %v", err)
+	}
+
+	return c
+}
+
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseValue(path string, lineNo int, raw string) string {
+	switch {
+	case strings.HasPrefix(raw, "\""):
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			Fatal("%s:%d: invalid string %s", path, lineNo, raw)
+		}
+		return value
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			Fatal("%s:%d: invalid string %s", path, lineNo, raw)
+		}
+		return raw[1 : len(raw)-1]
+	default:
+		return raw
+	}
+}
+
+func parseTimeout(path string, lineNo int, value string) time.Duration {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		Fatal("%s:%d: invalid timeout %s", path, lineNo, value)
+	}
+	return timeout
+}
+
+func resolvePath(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
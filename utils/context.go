@@ -0,0 +1,64 @@
+package utils
+
+import "encoding/binary"
+
+type Context struct {
+	Arch   string
+	Bits   int
+	Endian Endian
+}
+
+var Ctx = Context{Arch: "amd64", Bits: 64, Endian: LittleEndian}
+
+var contexts = map[string]Context{
+	"amd64":   {"amd64", 64, LittleEndian},
+	"x86_64":  {"amd64", 64, LittleEndian},
+	"i386":    {"i386", 32, LittleEndian},
+	"x86":     {"i386", 32, LittleEndian},
+	"aarch64": {"aarch64", 64, LittleEndian},
+	"arm64":   {"aarch64", 64, LittleEndian},
+	"arm":     {"arm", 32, LittleEndian},
+	"mips":    {"mips", 32, BigEndian},
+	"mipsel":  {"mipsel", 32, LittleEndian},
+	"riscv32": {"riscv32", 32, LittleEndian},
+	"riscv64": {"riscv64", 64, LittleEndian},
+}
+
+func SetContext(arch string) {
+	c, ok := contexts[arch]
+	if !ok {
+		Fatal("unsupported arch: %s", arch)
+	}
+	Ctx = c
+}
+
+func Arch(arch string) string {
+	if arch == "" {
+		return Ctx.Arch
+	}
+	if c, ok := contexts[arch]; ok {
+		return c.Arch
+	}
+	return arch
+}
+
+func Pack(v uint64) []byte {
+	if Ctx.Bits == 32 {
+		return P32(uint32(v))
+	}
+	return P64(v)
+}
+
+func Unpack(data []byte) uint64 {
+	if Ctx.Bits == 32 {
+		return uint64(U32(data))
+	}
+	return U64(data)
+}
+
+func (c Context) ByteOrder() binary.ByteOrder {
+	if c.Endian == BigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
@@ -67,10 +67,10 @@ func U64(data []byte, endian ...Endian) uint64 {
 }
 
 func getEndian(endian ...Endian) binary.ByteOrder {
-	if len(endian) > 0 && endian[0] == BigEndian {
-		return binary.BigEndian
+	if len(endian) > 0 {
+		return Context{Endian: endian[0]}.ByteOrder()
 	}
-	return binary.LittleEndian
+	return Ctx.ByteOrder()
 }
 
 func P16LE(v uint16) []byte { return P16(v, LittleEndian) }
@@ -5,6 +5,8 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"strconv"
+	"strings"
 )
 import "log"
 
@@ -52,8 +54,53 @@ func Type2Color(v interface{}) string {
 	}
 }
 
+const (
+	LogDebug = iota - 1
+	LogInfo
+	LogWarn
+	LogError
+)
+
+var LogLevel = LogInfo
+
 var logger = log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile)
 
+func ParseLogLevel(s string) int {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LogDebug
+	case "info":
+		return LogInfo
+	case "warn", "warning":
+		return LogWarn
+	case "error":
+		return LogError
+	}
+	level, err := strconv.Atoi(s)
+	if err != nil {
+		Fatal("invalid log level: %s", s)
+	}
+	return level
+}
+
+func Debug(format string, v ...interface{}) {
+	if LogLevel <= LogDebug {
+		fmt.Printf("%s[DEBUG] "+format+"%s\n", append(append([]interface{}{ColorGray}, v...), ColorReset)...)
+	}
+}
+
+func Info(format string, v ...interface{}) {
+	if LogLevel <= LogInfo {
+		fmt.Printf("%s[*]%s "+format+"\n", append([]interface{}{ColorBlue, ColorReset}, v...)...)
+	}
+}
+
+func Warn(format string, v ...interface{}) {
+	if LogLevel <= LogWarn {
+		fmt.Printf("%s[!]%s "+format+"\n", append([]interface{}{ColorYellow, ColorReset}, v...)...)
+	}
+}
+
 func Fatal(format string, v ...interface{}) {
 	pc, _, _, _ := runtime.Caller(1)
 	funcName := runtime.FuncForPC(pc).Name()